@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"backend/config"
+	"backend/internal/catalog"
+	"backend/internal/database"
+	"backend/internal/models"
+	"backend/internal/openfoodfacts"
+	"backend/internal/storage"
+)
+
+// importBatchSize is the number of products stored per transaction.
+const importBatchSize = 1000
+
+const commandsUsage = `Usage:
+  server                                      Run the bot
+  server import-off <file>                    Import an Open Food Facts export (.csv, .jsonl, optionally .gz)
+  server import-products [-owner user] <file>  Add or update products from a .csv or .json file
+  server export-products [-owner user] <file>  Write products to a .csv or .json file (- for stdout as CSV)
+  server claim-user <username> <telegram-id>   Hand data logged under an old username login over to a user
+
+Products go to the shared catalog unless -owner names the user whose own catalog to use,
+by Telegram ID or username.
+Files have the columns ` + "name,ccal,proteins,fats,carbs,unit,brand,barcode" + `; nutrition is per 100 g/ml or per piece.
+Only claim a login for a user who has shown it was theirs, since a username may have passed to someone else.`
+
+// runCommand runs a maintenance command given on the command line instead
+// of the bot.
+func runCommand(args []string) error {
+	switch args[0] {
+	case "import-off":
+		if len(args) != 2 {
+			return fmt.Errorf("expected a file name\n%s", commandsUsage)
+		}
+		return withDatabase(func(ctx context.Context, db *database.DB) error {
+			return importOpenFoodFacts(ctx, db, args[1])
+		})
+
+	case "import-products", "export-products":
+		flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+		owner := flags.String("owner", "", "Telegram ID or username of the user whose catalog to use instead of the shared one")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if flags.NArg() != 1 {
+			return fmt.Errorf("expected a file name\n%s", commandsUsage)
+		}
+
+		return withDatabase(func(ctx context.Context, db *database.DB) error {
+			ownerID, err := resolveOwner(ctx, db, *owner)
+			if err != nil {
+				return err
+			}
+
+			if args[0] == "import-products" {
+				return importProducts(ctx, db, ownerID, flags.Arg(0))
+			}
+			return exportProducts(ctx, db, ownerID, flags.Arg(0))
+		})
+
+	case "claim-user":
+		if len(args) != 3 {
+			return fmt.Errorf("expected a username and a Telegram ID\n%s", commandsUsage)
+		}
+		username := strings.TrimPrefix(args[1], "@")
+		id, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid Telegram ID %q", args[2])
+		}
+
+		return withDatabase(func(ctx context.Context, db *database.DB) error {
+			err := db.ClaimUser(ctx, username, id)
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("no data logged under the login %q", username)
+			}
+			if err != nil {
+				return err
+			}
+			log.Printf("Data logged under %q now belongs to user %d", username, id)
+			return nil
+		})
+	}
+
+	return fmt.Errorf("unknown command %q\n%s", args[0], commandsUsage)
+}
+
+// resolveOwner turns the -owner flag into the ID of a known user, or nil for
+// the shared catalog.
+func resolveOwner(ctx context.Context, users storage.Users, owner string) (*int64, error) {
+	if owner == "" {
+		return nil, nil
+	}
+
+	if id, err := strconv.ParseInt(owner, 10, 64); err == nil {
+		_, err := users.GetUsername(ctx, id)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("unknown user %d", id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &id, nil
+	}
+
+	id, err := users.GetUserIDByUsername(ctx, strings.TrimPrefix(owner, "@"))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("unknown user %q", owner)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// withDatabase connects to the migrated database for the duration of fn.
+// The context passed to fn is cancelled on interrupt, which rolls back the
+// transaction in progress.
+func withDatabase(fn func(ctx context.Context, db *database.DB) error) error {
+	db, err := database.NewConnection(config.LoadDatabaseConfig())
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer db.Close()
+
+	if err := db.RunMigrations("migrations"); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return fn(ctx, db)
+}
+
+// importOpenFoodFacts loads a local Open Food Facts export into the shared
+// catalog so barcodes can be looked up offline.
+func importOpenFoodFacts(ctx context.Context, db *database.DB, path string) error {
+	var batch []*models.ProductDetails
+	imported := 0
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := db.UpsertSharedProducts(ctx, batch); err != nil {
+			return err
+		}
+		imported += len(batch)
+		batch = batch[:0]
+		if imported%(100*importBatchSize) == 0 {
+			log.Printf("Imported %d products", imported)
+		}
+		return nil
+	}
+
+	stats, err := openfoodfacts.ReadFile(path, func(product *models.ProductDetails) error {
+		batch = append(batch, product)
+		if len(batch) < importBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return fmt.Errorf("import stopped after %d products: %w", imported, err)
+	}
+
+	log.Printf("Imported %d products, skipped %d rows without a valid barcode, name or nutrition", imported, stats.Skipped)
+	return nil
+}
+
+// importProducts adds or updates the products of a CSV or JSON file. The
+// file is validated as a whole before anything is stored.
+func importProducts(ctx context.Context, db *database.DB, owner *int64, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	products, err := catalog.Read(file, catalog.FormatOf(path))
+	if err != nil {
+		return fmt.Errorf("invalid file %s:\n%w", path, err)
+	}
+
+	created, updated, err := db.UpsertProducts(ctx, owner, products)
+	if err != nil {
+		return err
+	}
+
+	if owner != nil && updated > 0 {
+		if err := storage.RefreshRecipes(ctx, db, *owner); err != nil {
+			return fmt.Errorf("failed to refresh recipes: %w", err)
+		}
+	}
+
+	log.Printf("Imported %d products: %d new, %d updated", len(products), created, updated)
+	return nil
+}
+
+// exportProducts writes a catalog in the format of its file name.
+func exportProducts(ctx context.Context, db *database.DB, owner *int64, path string) error {
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	writer, err := catalog.NewWriter(w, catalog.FormatOf(path))
+	if err != nil {
+		return err
+	}
+	if err := db.EachProduct(ctx, owner, writer.Write); err != nil {
+		return err
+	}
+
+	count, err := writer.Close()
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Exported %d products", count)
+	return nil
+}
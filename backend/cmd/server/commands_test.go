@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"backend/internal/storage/memory"
+)
+
+func TestResolveOwner(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New()
+	if err := store.EnsureUser(ctx, 42, "alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		owner   string
+		want    int64
+		wantErr string
+	}{
+		{owner: "42", want: 42},
+		{owner: "alice", want: 42},
+		{owner: "@Alice", want: 42},
+		{owner: "7", wantErr: "unknown user 7"},
+		{owner: "@bob", wantErr: `unknown user "@bob"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.owner, func(t *testing.T) {
+			got, err := resolveOwner(ctx, store, tt.owner)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("resolveOwner(%q) = %v, %v; want error %q", tt.owner, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got == nil || *got != tt.want {
+				t.Errorf("resolveOwner(%q) = %v, %v; want %d", tt.owner, got, err, tt.want)
+			}
+		})
+	}
+
+	if got, err := resolveOwner(ctx, store, ""); got != nil || err != nil {
+		t.Errorf("resolveOwner(\"\") = %v, %v; want the shared catalog", got, err)
+	}
+}
+
+func TestClaimUserArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"claim-user", "alice", "-5"}, `invalid Telegram ID "-5"`},
+		{[]string{"claim-user", "@alice", "bob"}, `invalid Telegram ID "bob"`},
+	}
+
+	for _, tt := range tests {
+		if err := runCommand(tt.args); err == nil || err.Error() != tt.want {
+			t.Errorf("runCommand(%q) error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+
+	// A claim needs both the login and the user.
+	if err := runCommand([]string{"claim-user", "alice"}); err == nil || !strings.HasPrefix(err.Error(), "expected a username and a Telegram ID\n") {
+		t.Errorf("runCommand without an ID error = %v", err)
+	}
+}
@@ -1,32 +1,56 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
 	"time"
+	_ "time/tzdata"
 
 	"backend/config"
 	"backend/internal/bot"
+	"backend/internal/bot/conversation"
 	"backend/internal/bot/handlers"
 	"backend/internal/database"
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/scheduler"
+	"backend/internal/storage"
+	"backend/internal/storage/memory"
+	"backend/internal/timeout"
 
 	tele "gopkg.in/telebot.v3"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	cfg := config.LoadConfig()
 
 	if cfg.Bot.Token == "" {
 		log.Fatal("BOT_TOKEN environment variable is required")
 	}
 
-	db, err := database.NewConnection(&cfg.Database)
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
-	}
-	defer db.Close()
+	var store storage.Store
+	if cfg.Storage == config.StorageMemory {
+		log.Println("Keeping data in memory, it is lost when the bot stops")
+		store = memory.New()
+	} else {
+		db, err := database.NewConnection(&cfg.Database)
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+		defer db.Close()
 
-	if err := db.RunMigrations("migrations"); err != nil {
-		log.Fatal("Failed to run migrations:", err)
+		if err := db.RunMigrations("migrations"); err != nil {
+			log.Fatal("Failed to run migrations:", err)
+		}
+		store = db
 	}
 
 	pref := tele.Settings{
@@ -39,22 +63,51 @@ func main() {
 		log.Fatal("Failed to create bot:", err)
 	}
 
-	handler := bot.NewBotHandler(db)
-	menuHandler := handlers.NewMenuHandler(db)
+	conversations := conversation.New()
+	handler := bot.NewBotHandler(store, conversations)
+	menuHandler := handlers.NewMenuHandler(store, conversations)
+
+	b.Use(timeout.Middleware(cfg.Bot.UpdateTimeout))
+	b.Use(identity.Middleware(store.EnsureUser))
+	b.Use(i18n.Middleware(handler.StoredLang))
 
 	b.Handle("/start", handler.HandleStart)
 	b.Handle("/help", handler.HandleHelp)
 	b.Handle("/ping", handler.HandlePing)
 	b.Handle("/get", handler.HandleGet)
 	b.Handle("/today", handler.HandleGet)
+	b.Handle("/stats", handler.HandleStats)
+	b.Handle("/chart", handler.HandleChart)
 	b.Handle("/record", handler.HandleRecord)
+	b.Handle("/edit", handler.HandleEdit)
+	b.Handle("/delete", handler.HandleDelete)
+	b.Handle(&handler.BtnEditRecord, handler.HandleEditCallback)
+	b.Handle(&handler.BtnDeleteRecord, handler.HandleDeleteCallback)
+	b.Handle("/find", handler.HandleFind)
+	b.Handle("/recipe", handler.HandleRecipe)
+	b.Handle(&handler.BtnFindPick, handler.HandleFindPick)
+	b.Handle(tele.OnPhoto, handler.HandlePhoto)
+	b.Handle("/import", handler.HandleImport)
+	b.Handle("/export", handler.HandleExport)
+	b.Handle(tele.OnDocument, handler.HandleDocument)
+	b.Handle(tele.OnQuery, handler.HandleQuery)
+	b.Handle(tele.OnInlineResult, handler.HandleInlineResult)
+	b.Handle("/notify", handler.HandleNotify)
 	b.Handle("/set_noon", handler.HandleSetNoon)
+	b.Handle("/set_goal", handler.HandleSetGoal)
 	b.Handle("/set_lang", handler.HandleSetLang)
+	b.Handle("/set_tz", handler.HandleSetTimezone)
 	
 	b.Handle("/menu", menuHandler.HandleMenu)
 	b.Handle(&menuHandler.BtnLocations, menuHandler.HandleLocationsCallback)
 	b.Handle(tele.OnCallback, menuHandler.HandleCallback)
 
+	b.Handle("/cancel", conversations.HandleCancel)
+	b.Handle(tele.OnText, conversations.HandleText)
+	conversations.Fallback = handler.HandleBarcodeText
+
+	go scheduler.New(store, b).Run(context.Background())
+
 	log.Println("Bot started successfully!")
 	b.Start()
 }
@@ -3,13 +3,20 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 type Config struct {
+	Storage  string
 	Database DatabaseConfig
 	Bot      BotConfig
 }
 
+// StorageMemory keeps all data in memory instead of PostgreSQL, for local
+// demos. Everything is lost when the bot stops.
+const StorageMemory = "memory"
+
 type DatabaseConfig struct {
 	Host     string
 	Port     string
@@ -17,10 +24,21 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+
+	// Pool limits. Idle connections are closed after ConnMaxIdleTime and
+	// every connection after ConnMaxLifetime, so connections to a server
+	// that went away during a failover do not linger in the pool.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxIdleTime time.Duration
+	ConnMaxLifetime time.Duration
 }
 
 type BotConfig struct {
 	Token string
+	// UpdateTimeout bounds the handling of a single update, including its
+	// database queries.
+	UpdateTimeout time.Duration
 }
 
 func (db *DatabaseConfig) GetConnectionString() string {
@@ -34,31 +52,42 @@ func (db *DatabaseConfig) GetConnectionString() string {
 }
 
 func LoadConfig() *Config {
-	connStr := os.Getenv("DB_CONN_STRING")
 	token := requireEnv("BOT_TOKEN")
 	
-	if connStr != "" {
-		return &Config{
-			Database: DatabaseConfig{},
-			Bot: BotConfig{
-				Token: token,
-			},
-		}
-	}
-	
-	return &Config{
-		Database: DatabaseConfig{
-			Host:     requireEnv("DB_HOST"),
-			Port:     requireEnv("DB_PORT"),
-			User:     requireEnv("DB_USER"),
-			Password: requireEnv("DB_PASSWORD"),
-			DBName:   requireEnv("DB_NAME"),
-			SSLMode:  requireEnv("DB_SSLMODE"),
-		},
+	cfg := &Config{
+		Storage: os.Getenv("STORAGE"),
 		Bot: BotConfig{
-			Token: token,
+			Token:         token,
+			UpdateTimeout: durationEnv("BOT_UPDATE_TIMEOUT", 30*time.Second),
 		},
 	}
+	if cfg.Storage != StorageMemory {
+		cfg.Database = *LoadDatabaseConfig()
+	}
+	
+	return cfg
+}
+
+// LoadDatabaseConfig loads only the database settings, for commands that
+// work with the database without running the bot.
+func LoadDatabaseConfig() *DatabaseConfig {
+	cfg := &DatabaseConfig{
+		MaxOpenConns:    intEnv("DB_MAX_OPEN_CONNS", 10),
+		MaxIdleConns:    intEnv("DB_MAX_IDLE_CONNS", 5),
+		ConnMaxIdleTime: durationEnv("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+		ConnMaxLifetime: durationEnv("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+	}
+	if os.Getenv("DB_CONN_STRING") != "" {
+		return cfg
+	}
+	
+	cfg.Host = requireEnv("DB_HOST")
+	cfg.Port = requireEnv("DB_PORT")
+	cfg.User = requireEnv("DB_USER")
+	cfg.Password = requireEnv("DB_PASSWORD")
+	cfg.DBName = requireEnv("DB_NAME")
+	cfg.SSLMode = requireEnv("DB_SSLMODE")
+	return cfg
 }
 
 func requireEnv(key string) string {
@@ -69,3 +98,30 @@ func requireEnv(key string) string {
 	return value
 }
 
+// intEnv reads an optional integer variable, falling back to def when it is
+// not set.
+func intEnv(key string, def int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		panic(fmt.Sprintf("Environment variable %s must be an integer, got %q", key, value))
+	}
+	return n
+}
+
+// durationEnv reads an optional duration variable such as "30s", falling back
+// to def when it is not set.
+func durationEnv(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		panic(fmt.Sprintf("Environment variable %s must be a positive duration like 30s, got %q", key, value))
+	}
+	return d
+}
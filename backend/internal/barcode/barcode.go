@@ -0,0 +1,388 @@
+// Package barcode reads EAN-13, UPC-A and EAN-8 product barcodes from
+// photos without any native dependencies.
+package barcode
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math"
+)
+
+// ErrNotFound is returned when no barcode can be read from an image.
+var ErrNotFound = errors.New("no barcode found")
+
+// scanlines is the number of rows and columns sampled across the image.
+const scanlines = 32
+
+// maxDigitError is the largest difference, in modules, between the measured
+// widths of a digit and its pattern that is still accepted.
+const maxDigitError = 1.6
+
+// digitWidths are the module widths of the L-code of every digit, starting
+// with a space. G-codes are the same widths reversed and R-codes the same
+// widths starting with a bar.
+var digitWidths = [10][4]float64{
+	{3, 2, 1, 1},
+	{2, 2, 2, 1},
+	{2, 1, 2, 2},
+	{1, 4, 1, 1},
+	{1, 1, 3, 2},
+	{1, 2, 3, 1},
+	{1, 1, 1, 4},
+	{1, 3, 1, 2},
+	{1, 2, 1, 3},
+	{3, 1, 1, 2},
+}
+
+// firstDigitParity encodes the first EAN-13 digit in the L/G parity of the
+// six left digits.
+var firstDigitParity = [10]string{
+	"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG",
+	"LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL",
+}
+
+// Decode finds a barcode in img and returns its digits. UPC-A codes are
+// returned as 13-digit EAN codes with a leading zero.
+func Decode(img image.Image) (string, error) {
+	gray := toGray(img)
+	bounds := gray.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	for i := 1; i <= scanlines; i++ {
+		// Start in the middle, where the barcode usually is, and move out.
+		offset := (i / 2) * (1 - 2*(i%2))
+		y := height/2 + offset*height/(scanlines+1)
+		if code, ok := decodeLine(row(gray, y)); ok {
+			return code, nil
+		}
+
+		x := width/2 + offset*width/(scanlines+1)
+		if code, ok := decodeLine(column(gray, x)); ok {
+			return code, nil
+		}
+	}
+
+	return "", ErrNotFound
+}
+
+// Valid reports whether code is an EAN-8, UPC-A, EAN-13 or GTIN-14 number
+// with a correct check digit.
+func Valid(code string) bool {
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return checksum(code)
+}
+
+// Variants returns the spellings code may be stored under: UPC-A codes are
+// also known as 13-digit EAN codes with a leading zero and vice versa.
+func Variants(code string) []string {
+	variants := []string{code}
+	switch {
+	case len(code) == 12:
+		variants = append(variants, "0"+code)
+	case len(code) == 13 && code[0] == '0':
+		variants = append(variants, code[1:])
+	}
+	return variants
+}
+
+// checksum verifies the last digit of a GTIN: the other digits weigh 3 and
+// 1 alternately, starting with 3 next to the check digit.
+func checksum(code string) bool {
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
+
+func toGray(img image.Image) *image.Gray {
+	if gray, ok := img.(*image.Gray); ok {
+		return gray
+	}
+
+	bounds := img.Bounds()
+	gray := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			gray.Set(x, y, color.GrayModel.Convert(img.At(x, y)))
+		}
+	}
+	return gray
+}
+
+// row returns the brightness along row y, averaged with its neighbours to
+// smooth out noise.
+func row(gray *image.Gray, y int) []float64 {
+	bounds := gray.Bounds()
+	line := make([]float64, bounds.Dx())
+	for x := range line {
+		line[x] = average(gray, bounds.Min.X+x, bounds.Min.Y+y, 0, 1)
+	}
+	return line
+}
+
+// column is row for a vertical line, used for barcodes photographed sideways.
+func column(gray *image.Gray, x int) []float64 {
+	bounds := gray.Bounds()
+	line := make([]float64, bounds.Dy())
+	for y := range line {
+		line[y] = average(gray, bounds.Min.X+x, bounds.Min.Y+y, 1, 0)
+	}
+	return line
+}
+
+func average(gray *image.Gray, x, y, dx, dy int) float64 {
+	bounds := gray.Bounds()
+	var sum, n float64
+	for i := -1; i <= 1; i++ {
+		p := image.Pt(x+i*dx, y+i*dy)
+		if p.In(bounds) {
+			sum += float64(gray.GrayAt(p.X, p.Y).Y)
+			n++
+		}
+	}
+	return sum / n
+}
+
+// decodeLine looks for a barcode along a line of brightness values, read in
+// both directions so upside-down barcodes are found too.
+func decodeLine(line []float64) (string, bool) {
+	for _, window := range []int{0, len(line) / 8} {
+		widths, dark := runs(line, thresholds(line, window))
+		if code, ok := decodeRuns(widths, dark); ok {
+			return code, true
+		}
+
+		reverse(widths)
+		if code, ok := decodeRuns(widths, dark == (len(widths)%2 == 1)); ok {
+			return code, true
+		}
+	}
+
+	return "", false
+}
+
+// thresholds returns the brightness below which each pixel is dark. A zero
+// window uses a single threshold for the whole line, otherwise each pixel
+// is compared to its surroundings, which copes with uneven lighting. Pixels
+// without enough contrast around them get a zero threshold, i.e. are light.
+func thresholds(line []float64, window int) []float64 {
+	result := make([]float64, len(line))
+	if len(line) == 0 {
+		return result
+	}
+
+	if window == 0 {
+		low, high := bounds(line)
+		if high-low < 32 {
+			return result
+		}
+		for i := range result {
+			result[i] = (low + high) / 2
+		}
+		return result
+	}
+
+	for i := range line {
+		low, high := bounds(line[max(0, i-window):min(len(line), i+window+1)])
+		if high-low >= 32 {
+			result[i] = (low + high) / 2
+		}
+	}
+	return result
+}
+
+func bounds(line []float64) (float64, float64) {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range line {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+	return low, high
+}
+
+// runs returns the lengths of same-colored stretches of the line and
+// whether the first one is dark. Edges are placed where the brightness
+// crosses the threshold between two pixels, so narrow bars keep their
+// width in small images.
+func runs(line, thresholds []float64) ([]float64, bool) {
+	if len(line) == 0 {
+		return nil, false
+	}
+
+	var widths []float64
+	firstDark := line[0] < thresholds[0]
+	dark, edge := firstDark, 0.0
+	for i := 1; i < len(line); i++ {
+		if (line[i] < thresholds[i]) == dark {
+			continue
+		}
+
+		position := float64(i)
+		if delta := line[i] - line[i-1]; delta != 0 {
+			crossing := float64(i-1) + (thresholds[i]-line[i-1])/delta
+			position = math.Max(float64(i-1), math.Min(float64(i), crossing))
+		}
+		widths = append(widths, position-edge)
+		dark, edge = !dark, position
+	}
+	widths = append(widths, float64(len(line))-edge)
+
+	return widths, firstDark
+}
+
+func reverse(widths []float64) {
+	for i, j := 0, len(widths)-1; i < j; i, j = i+1, j-1 {
+		widths[i], widths[j] = widths[j], widths[i]
+	}
+}
+
+// decodeRuns tries every dark run as the start of an EAN-13 or EAN-8 code.
+func decodeRuns(widths []float64, firstDark bool) (string, bool) {
+	start := 0
+	if !firstDark {
+		start = 1
+	}
+
+	for i := start; i < len(widths); i += 2 {
+		if i+59 <= len(widths) {
+			if code, ok := decodeEAN13(widths[i : i+59]); ok {
+				return code, true
+			}
+		}
+		if i+43 <= len(widths) {
+			if code, ok := decodeEAN8(widths[i : i+43]); ok {
+				return code, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// decodeEAN13 decodes the 59 runs of an EAN-13 code: a 3-run start guard,
+// six 4-run digits, a 5-run middle guard, six more digits and an end guard,
+// 95 modules in total.
+func decodeEAN13(widths []float64) (string, bool) {
+	module := sum(widths) / 95
+	if !guard(widths[0:3], module) || !guard(widths[27:32], module) || !guard(widths[56:59], module) {
+		return "", false
+	}
+
+	code := make([]byte, 13)
+	parity := make([]byte, 6)
+	for i := 0; i < 6; i++ {
+		digit, p, ok := matchDigit(widths[3+4*i:7+4*i], true)
+		if !ok {
+			return "", false
+		}
+		code[i+1] = '0' + byte(digit)
+		parity[i] = p
+	}
+	for i := 0; i < 6; i++ {
+		digit, _, ok := matchDigit(widths[32+4*i:36+4*i], false)
+		if !ok {
+			return "", false
+		}
+		code[i+7] = '0' + byte(digit)
+	}
+
+	first := -1
+	for digit, p := range firstDigitParity {
+		if p == string(parity) {
+			first = digit
+		}
+	}
+	if first == -1 {
+		return "", false
+	}
+	code[0] = '0' + byte(first)
+
+	return string(code), checksum(string(code))
+}
+
+// decodeEAN8 decodes the 43 runs of an EAN-8 code, laid out like EAN-13
+// with four L-coded digits on each side and 67 modules in total.
+func decodeEAN8(widths []float64) (string, bool) {
+	module := sum(widths) / 67
+	if !guard(widths[0:3], module) || !guard(widths[19:24], module) || !guard(widths[40:43], module) {
+		return "", false
+	}
+
+	code := make([]byte, 8)
+	for i := 0; i < 4; i++ {
+		digit, p, ok := matchDigit(widths[3+4*i:7+4*i], false)
+		if !ok || p != 'L' {
+			return "", false
+		}
+		code[i] = '0' + byte(digit)
+
+		digit, _, ok = matchDigit(widths[24+4*i:28+4*i], false)
+		if !ok {
+			return "", false
+		}
+		code[i+4] = '0' + byte(digit)
+	}
+
+	return string(code), checksum(string(code))
+}
+
+// guard reports whether all runs of a guard pattern are one module wide.
+func guard(widths []float64, module float64) bool {
+	for _, w := range widths {
+		if w < module*0.4 || w > module*1.8 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchDigit returns the digit whose pattern is closest to the four runs
+// and its parity, 'L' or 'G'. G-codes are only considered when allowG.
+func matchDigit(widths []float64, allowG bool) (int, byte, bool) {
+	total := sum(widths)
+	best, bestParity, bestError := -1, byte('L'), math.Inf(1)
+
+	for digit, pattern := range digitWidths {
+		var errL, errG float64
+		for j := range pattern {
+			measured := widths[j] * 7 / total
+			errL += math.Abs(measured - pattern[j])
+			errG += math.Abs(measured - pattern[3-j])
+		}
+
+		if errL < bestError {
+			best, bestParity, bestError = digit, 'L', errL
+		}
+		if allowG && errG < bestError {
+			best, bestParity, bestError = digit, 'G', errG
+		}
+	}
+
+	return best, bestParity, bestError <= maxDigitError
+}
+
+func sum(widths []float64) float64 {
+	var total float64
+	for _, w := range widths {
+		total += w
+	}
+	return total
+}
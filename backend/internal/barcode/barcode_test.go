@@ -0,0 +1,199 @@
+package barcode
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+// modules returns the bars of an EAN-13 or EAN-8 code, true for dark.
+func modules(code string) []bool {
+	var bars []bool
+	put := func(dark bool, widths ...float64) {
+		for _, w := range widths {
+			for range int(w) {
+				bars = append(bars, dark)
+			}
+			dark = !dark
+		}
+	}
+	digit := func(d byte, parity byte) {
+		widths := digitWidths[d-'0']
+		switch parity {
+		case 'L':
+			put(false, widths[:]...)
+		case 'G':
+			put(false, widths[3], widths[2], widths[1], widths[0])
+		case 'R':
+			put(true, widths[:]...)
+		}
+	}
+
+	left, right, parity := code[:4], code[4:], "LLLL"
+	if len(code) == 13 {
+		left, right, parity = code[1:7], code[7:], firstDigitParity[code[0]-'0']
+	}
+
+	put(true, 1, 1, 1)
+	for i := range left {
+		digit(left[i], parity[i])
+	}
+	put(false, 1, 1, 1, 1, 1)
+	for i := range right {
+		digit(right[i], 'R')
+	}
+	put(true, 1, 1, 1)
+	return bars
+}
+
+// render draws the code with a quiet zone, scale pixels per module.
+func render(code string, scale int) *image.Gray {
+	bars := modules(code)
+	quiet := 10 * scale
+	img := image.NewGray(image.Rect(0, 0, len(bars)*scale+2*quiet, 40*scale))
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			i := (x - quiet) / scale
+			if x >= quiet && i < len(bars) && bars[i] {
+				img.SetGray(x, y, color.Gray{Y: 20})
+			} else {
+				img.SetGray(x, y, color.Gray{Y: 235})
+			}
+		}
+	}
+	return img
+}
+
+func transform(src *image.Gray, at func(x, y, w, h int) (int, int), w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			sx, sy := at(x, y, w, h)
+			img.SetGray(x, y, src.GrayAt(sx, sy))
+		}
+	}
+	return img
+}
+
+func mirror(src *image.Gray) *image.Gray {
+	b := src.Bounds()
+	return transform(src, func(x, y, w, h int) (int, int) { return w - 1 - x, y }, b.Dx(), b.Dy())
+}
+
+func upsideDown(src *image.Gray) *image.Gray {
+	b := src.Bounds()
+	return transform(src, func(x, y, w, h int) (int, int) { return w - 1 - x, h - 1 - y }, b.Dx(), b.Dy())
+}
+
+func sideways(src *image.Gray) *image.Gray {
+	b := src.Bounds()
+	return transform(src, func(x, y, w, h int) (int, int) { return y, w - 1 - x }, b.Dy(), b.Dx())
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		img  image.Image
+		want string
+	}{
+		{"EAN-13", render("4006381333931", 3), "4006381333931"},
+		{"EAN-13 one pixel per module", render("5901234123457", 1), "5901234123457"},
+		{"UPC-A", render("0036000291452", 2), "0036000291452"},
+		{"EAN-8", render("96385074", 3), "96385074"},
+		{"mirrored", mirror(render("4006381333931", 3)), "4006381333931"},
+		{"mirrored EAN-8", mirror(render("96385074", 3)), "96385074"},
+		{"upside down", upsideDown(render("4006381333931", 3)), "4006381333931"},
+		{"sideways", sideways(render("4006381333931", 3)), "4006381333931"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.img)
+			if err != nil || got != tt.want {
+				t.Errorf("Decode = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeNotFound(t *testing.T) {
+	blank := image.NewGray(image.Rect(0, 0, 300, 120))
+	for i := range blank.Pix {
+		blank.Pix[i] = 235
+	}
+
+	// Bars that are not a barcode.
+	stripes := image.NewGray(image.Rect(0, 0, 300, 120))
+	for y := range 120 {
+		for x := range 300 {
+			if x/7%2 == 0 {
+				stripes.SetGray(x, y, color.Gray{Y: 20})
+			} else {
+				stripes.SetGray(x, y, color.Gray{Y: 235})
+			}
+		}
+	}
+
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{"blank", blank},
+		{"stripes", stripes},
+		{"wrong check digit", render("4006381333932", 3)},
+		{"wrong EAN-8 check digit", render("96385075", 3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Decode(tt.img); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Decode = %q, %v; want %v", got, err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"4006381333931", true},
+		{"036000291452", true},
+		{"96385074", true},
+		{"10036000291459", true},
+		{"4006381333932", false},
+		{"036000291453", false},
+		{"96385075", false},
+		{"400638133393", false},
+		{"40063813339a1", false},
+		{"123", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := Valid(tt.code); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestVariants(t *testing.T) {
+	tests := []struct {
+		code string
+		want []string
+	}{
+		{"036000291452", []string{"036000291452", "0036000291452"}},
+		{"0036000291452", []string{"0036000291452", "036000291452"}},
+		{"4006381333931", []string{"4006381333931"}},
+		{"96385074", []string{"96385074"}},
+	}
+
+	for _, tt := range tests {
+		if got := Variants(tt.code); !slices.Equal(got, tt.want) {
+			t.Errorf("Variants(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"strings"
+
+	"backend/internal/barcode"
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/timeout"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// HandleBarcodeText looks up a barcode sent as plain digits in a private
+// chat. Other text outside of a conversation is ignored.
+func (h *BotHandler) HandleBarcodeText(c tele.Context) error {
+	p := i18n.From(c)
+	if c.Chat().Type != tele.ChatPrivate {
+		return nil
+	}
+	code := strings.ReplaceAll(strings.TrimSpace(c.Text()), " ", "")
+	if code == "" || strings.Trim(code, "0123456789") != "" {
+		return nil
+	}
+
+	if !barcode.Valid(code) {
+		return c.Send(p.Sprintf("%s is not a valid EAN/UPC barcode, check the digits", code))
+	}
+
+	return h.lookupBarcode(c, code)
+}
+
+// HandlePhoto reads a barcode from a photo of a product package sent in a
+// private chat. Photos in groups are left alone.
+func (h *BotHandler) HandlePhoto(c tele.Context) error {
+	p := i18n.From(c)
+	if c.Chat().Type != tele.ChatPrivate {
+		return nil
+	}
+	photo := c.Message().Photo
+
+	file, err := c.Bot().File(&photo.File)
+	if err != nil {
+		log.Printf("Error downloading photo: %v", err)
+		return c.Send(p.Sprintf("❌ Error downloading photo: %v", err))
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		log.Printf("Error decoding photo: %v", err)
+		return c.Send(p.Sprintf("❌ Error reading photo: %v", err))
+	}
+
+	code, err := barcode.Decode(img)
+	if errors.Is(err, barcode.ErrNotFound) {
+		return c.Send(p.T("🤷 Couldn't read a barcode. Take the photo closer with the barcode level and in focus, or send its digits"))
+	}
+	if err != nil {
+		return c.Send(p.Sprintf("❌ Error reading barcode: %v", err))
+	}
+
+	return h.lookupBarcode(c, code)
+}
+
+// lookupBarcode finds the product with the barcode and asks how much of it
+// the user ate.
+func (h *BotHandler) lookupBarcode(c tele.Context, code string) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	userID := identity.From(c)
+
+	product, err := h.db.GetProductByBarcode(ctx, userID, barcode.Variants(code))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Send(p.Sprintf("🤷 No product with barcode %s in the catalog.\n"+
+			"Record it once with its nutrition: /record <name> 100g <ccal> [proteins] [fats] [carbs]", code))
+	}
+	if err != nil {
+		log.Printf("Error finding product by barcode: %v", err)
+		return c.Send(p.Sprintf("❌ Error finding product: %v", err))
+	}
+
+	h.askAmount(c, userID, product)
+
+	return c.Send(p.Sprintf("%s\n📦 %s\nP %d · F %d · C %d\n\n%s",
+		productLabel(p, product), code, product.Proteins, product.Fats, product.Carbs, amountPrompt(p, product)))
+}
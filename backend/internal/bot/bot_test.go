@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/bot/bottest"
+	"backend/internal/bot/conversation"
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/storage/memory"
+	"backend/internal/timeout"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// newTestBot wires a handler into a bot talking to a fake Bot API, with the
+// middleware of the real bot.
+func newTestBot(t *testing.T) (*bottest.Server, *tele.Bot, *memory.Store) {
+	t.Helper()
+
+	server := bottest.NewServer()
+	t.Cleanup(server.Close)
+
+	b, err := server.NewBot()
+	if err != nil {
+		t.Fatalf("creating bot: %v", err)
+	}
+
+	store := memory.New()
+	handler := NewBotHandler(store, conversation.New())
+
+	b.Use(timeout.Middleware(time.Minute))
+	b.Use(identity.Middleware(store.EnsureUser))
+	b.Use(i18n.Middleware(handler.StoredLang))
+
+	b.Handle("/start", handler.HandleStart)
+	b.Handle("/get", handler.HandleGet)
+	b.Handle("/record", handler.HandleRecord)
+	b.Handle("/set_lang", handler.HandleSetLang)
+	b.Handle(&handler.BtnDeleteRecord, handler.HandleDeleteCallback)
+
+	return server, b, store
+}
+
+func TestBotLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		commands []string
+		want     string
+	}{
+		{"client language", "en", []string{"/start"}, "Welcome to C-Meter! 👋\n\nUse /help to see available commands."},
+		{"unsupported client language", "de", []string{"/start"}, "Добро пожаловать в C-Meter! 👋\n\nСписок команд - /help."},
+		{"chosen language", "ru", []string{"/set_lang en", "/start"}, "Welcome to C-Meter! 👋\n\nUse /help to see available commands."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, b, _ := newTestBot(t)
+			user := &tele.User{ID: 42, Username: "alice", LanguageCode: tt.language}
+
+			for _, command := range tt.commands {
+				b.ProcessUpdate(bottest.MessageUpdate(user, command))
+			}
+
+			calls := server.Calls("sendMessage")
+			if len(calls) != len(tt.commands) {
+				t.Fatalf("sent %d messages, want %d", len(calls), len(tt.commands))
+			}
+			if got := calls[len(calls)-1].Params["text"]; got != tt.want {
+				t.Errorf("reply %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBotRecordAndDelete(t *testing.T) {
+	server, b, store := newTestBot(t)
+
+	b.ProcessUpdate(bottest.MessageUpdate(alice, "/record Apple 2pcs 52 0 0 14 #snack"))
+	if errs := server.Errors(); len(errs) > 0 {
+		t.Fatalf("handler errors: %v", errs)
+	}
+
+	if id, err := store.GetUserIDByUsername(context.Background(), "alice"); err != nil || id != alice.ID {
+		t.Errorf("user stored as %d, %v; want %d", id, err, alice.ID)
+	}
+
+	sent := server.Calls("sendMessage")
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	if got := sent[0].Params["chat_id"]; got != "42" {
+		t.Errorf("sent to chat %s, want 42", got)
+	}
+	text := sent[0].Params["text"]
+	if !strings.HasPrefix(text, "✅ Recorded: Apple (2 pcs)\n📊 Calories: 104\n") {
+		t.Errorf("unexpected reply %q", text)
+	}
+
+	id := text[strings.LastIndex(text, "ID: ")+len("ID: "):]
+	if !strings.Contains(sent[0].Params["reply_markup"], `\frecord_delete|`+id) {
+		t.Errorf("reply markup %s has no delete button for %s", sent[0].Params["reply_markup"], id)
+	}
+
+	server.Reset()
+	b.ProcessUpdate(bottest.CallbackUpdate(alice, "\frecord_delete|"+id))
+	if errs := server.Errors(); len(errs) > 0 {
+		t.Fatalf("handler errors: %v", errs)
+	}
+
+	answers := server.Calls("answerCallbackQuery")
+	if len(answers) != 1 || answers[0].Params["text"] != "Record deleted" {
+		t.Errorf("callback answers %+v, want Record deleted", answers)
+	}
+	if edits := server.Calls("editMessageText"); len(edits) != 1 {
+		t.Errorf("edited %d messages, want 1", len(edits))
+	}
+
+	server.Reset()
+	b.ProcessUpdate(bottest.MessageUpdate(alice, "/get"))
+	sent = server.Calls("sendMessage")
+	if len(sent) != 1 || sent[0].Params["text"] != "No records found for the last day" {
+		t.Errorf("sent %+v after deleting the only record", sent)
+	}
+}
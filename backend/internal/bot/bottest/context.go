@@ -0,0 +1,326 @@
+// Package bottest helps testing bot handlers without Telegram. A Context is
+// passed straight to a handler and records what it replies; a Server is a
+// fake Bot API for running a whole bot with its middleware and routing.
+package bottest
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// cmdRx splits a command from its payload the way telebot does when it
+// processes an update.
+var cmdRx = regexp.MustCompile(`^(/\w+)(@(\w+))?(\s|$)(.+)?`)
+
+var (
+	offlineOnce sync.Once
+	offline     *tele.Bot
+)
+
+// offlineBot is the bot contexts read updates through. It never talks to
+// Telegram.
+func offlineBot() *tele.Bot {
+	offlineOnce.Do(func() {
+		b, err := tele.NewBot(tele.Settings{Offline: true, Synchronous: true})
+		if err != nil {
+			panic(err)
+		}
+		offline = b
+	})
+	return offline
+}
+
+// Reply is a message a handler sent or edited.
+type Reply struct {
+	// Text is the text of the message or the caption of a file.
+	Text    string
+	What    interface{}
+	Options []interface{}
+}
+
+// Markup returns the reply markup passed with the message, or nil.
+func (r Reply) Markup() *tele.ReplyMarkup {
+	for _, opt := range r.Options {
+		switch opt := opt.(type) {
+		case *tele.ReplyMarkup:
+			return opt
+		case *tele.SendOptions:
+			if opt.ReplyMarkup != nil {
+				return opt.ReplyMarkup
+			}
+		}
+	}
+	return nil
+}
+
+// Buttons returns the callback data of the inline buttons row by row, as
+// Telegram sends it back when a button is pressed.
+func (r Reply) Buttons() [][]string {
+	markup := r.Markup()
+	if markup == nil {
+		return nil
+	}
+
+	rows := make([][]string, len(markup.InlineKeyboard))
+	for i, row := range markup.InlineKeyboard {
+		for _, btn := range row {
+			rows[i] = append(rows[i], callbackData(btn))
+		}
+	}
+	return rows
+}
+
+// callbackData formats the data of a button like telebot does when sending
+// it: "\f<unique>|<data>" for buttons with a unique name.
+func callbackData(btn tele.InlineButton) string {
+	switch {
+	case btn.Unique == "":
+		return btn.Data
+	case btn.Data == "":
+		return "\f" + btn.Unique
+	default:
+		return "\f" + btn.Unique + "|" + btn.Data
+	}
+}
+
+// ParseMode returns the parse mode the message was sent with.
+func (r Reply) ParseMode() tele.ParseMode {
+	for _, opt := range r.Options {
+		switch opt := opt.(type) {
+		case tele.ParseMode:
+			return opt
+		case *tele.SendOptions:
+			return opt.ParseMode
+		}
+	}
+	return tele.ModeDefault
+}
+
+// Context is a tele.Context whose outgoing calls are recorded instead of
+// sent. Everything else, such as Sender, Args or Get, reads the update like
+// the context of a real bot.
+type Context struct {
+	tele.Context
+
+	mu        sync.Mutex
+	Sent      []Reply
+	Edited    []Reply
+	Responses []*tele.CallbackResponse
+	Answers   []*tele.QueryResponse
+	Deleted   bool
+}
+
+// New returns a context for the update.
+func New(u tele.Update) *Context {
+	return &Context{Context: offlineBot().NewContext(u)}
+}
+
+// NewMessage returns a context for a text message the user sent to the bot
+// in a private chat. Commands get their payload like in a real bot, so Args
+// returns the words after the command.
+func NewMessage(user *tele.User, text string) *Context {
+	u := MessageUpdate(user, text)
+	if match := cmdRx.FindStringSubmatch(text); match != nil {
+		u.Message.Payload = match[5]
+	}
+	return New(u)
+}
+
+// NewCallback returns a context for the user pressing an inline button
+// with the given data under a message of the bot.
+func NewCallback(user *tele.User, data string) *Context {
+	return New(CallbackUpdate(user, data))
+}
+
+// NewQuery returns a context for an inline query of the user.
+func NewQuery(user *tele.User, text string) *Context {
+	return New(tele.Update{Query: &tele.Query{ID: "1", Sender: user, Text: text}})
+}
+
+// MessageUpdate returns an update with a text message the user sent to
+// the bot in a private chat.
+func MessageUpdate(user *tele.User, text string) tele.Update {
+	return tele.Update{Message: &tele.Message{
+		ID:       1,
+		Sender:   user,
+		Chat:     privateChat(user),
+		Text:     text,
+		Unixtime: time.Now().Unix(),
+	}}
+}
+
+// CallbackUpdate returns an update with the user pressing an inline button
+// with the given data under a message of the bot.
+func CallbackUpdate(user *tele.User, data string) tele.Update {
+	return tele.Update{Callback: &tele.Callback{
+		ID:     "1",
+		Sender: user,
+		Data:   data,
+		Message: &tele.Message{
+			ID:       1,
+			Sender:   offlineBot().Me,
+			Chat:     privateChat(user),
+			Unixtime: time.Now().Unix(),
+		},
+	}}
+}
+
+func privateChat(user *tele.User) *tele.Chat {
+	return &tele.Chat{ID: user.ID, Type: tele.ChatPrivate, Username: user.Username, FirstName: user.FirstName}
+}
+
+// LastSent returns the last message sent, or an empty reply.
+func (c *Context) LastSent() Reply {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.Sent) == 0 {
+		return Reply{}
+	}
+	return c.Sent[len(c.Sent)-1]
+}
+
+// LastEdited returns the last edit, or an empty reply.
+func (c *Context) LastEdited() Reply {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.Edited) == 0 {
+		return Reply{}
+	}
+	return c.Edited[len(c.Edited)-1]
+}
+
+// LastResponse returns the last callback response, or nil.
+func (c *Context) LastResponse() *tele.CallbackResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.Responses) == 0 {
+		return nil
+	}
+	return c.Responses[len(c.Responses)-1]
+}
+
+func (c *Context) Send(what interface{}, opts ...interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Sent = append(c.Sent, newReply(what, opts))
+	return nil
+}
+
+func (c *Context) SendAlbum(a tele.Album, opts ...interface{}) error {
+	return c.Send(a, opts...)
+}
+
+func (c *Context) Reply(what interface{}, opts ...interface{}) error {
+	return c.Send(what, opts...)
+}
+
+func (c *Context) Forward(msg tele.Editable, opts ...interface{}) error {
+	return c.Send(msg, opts...)
+}
+
+func (c *Context) ForwardTo(to tele.Recipient, opts ...interface{}) error {
+	return nil
+}
+
+func (c *Context) Edit(what interface{}, opts ...interface{}) error {
+	if c.Callback() == nil && c.InlineResult() == nil {
+		return tele.ErrBadContext
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Edited = append(c.Edited, newReply(what, opts))
+	return nil
+}
+
+func (c *Context) EditCaption(caption string, opts ...interface{}) error {
+	return c.Edit(caption, opts...)
+}
+
+func (c *Context) EditOrSend(what interface{}, opts ...interface{}) error {
+	err := c.Edit(what, opts...)
+	if err == tele.ErrBadContext {
+		return c.Send(what, opts...)
+	}
+	return err
+}
+
+func (c *Context) EditOrReply(what interface{}, opts ...interface{}) error {
+	return c.EditOrSend(what, opts...)
+}
+
+func (c *Context) Delete() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Deleted = true
+	return nil
+}
+
+func (c *Context) DeleteAfter(d time.Duration) *time.Timer {
+	return time.AfterFunc(d, func() {
+		c.Delete()
+	})
+}
+
+func (c *Context) Notify(action tele.ChatAction) error {
+	return nil
+}
+
+func (c *Context) Ship(what ...interface{}) error {
+	return nil
+}
+
+func (c *Context) Accept(errorMessage ...string) error {
+	return nil
+}
+
+func (c *Context) Answer(resp *tele.QueryResponse) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Answers = append(c.Answers, resp)
+	return nil
+}
+
+func (c *Context) Respond(resp ...*tele.CallbackResponse) error {
+	response := &tele.CallbackResponse{}
+	if len(resp) > 0 {
+		response = resp[0]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.Responses = append(c.Responses, response)
+	return nil
+}
+
+func (c *Context) RespondText(text string) error {
+	return c.Respond(&tele.CallbackResponse{Text: text})
+}
+
+func (c *Context) RespondAlert(text string) error {
+	return c.Respond(&tele.CallbackResponse{Text: text, ShowAlert: true})
+}
+
+func newReply(what interface{}, opts []interface{}) Reply {
+	reply := Reply{What: what, Options: opts}
+	switch what := what.(type) {
+	case string:
+		reply.Text = what
+	case *tele.Photo:
+		reply.Text = what.Caption
+	case *tele.Document:
+		reply.Text = what.Caption
+	}
+	return reply
+}
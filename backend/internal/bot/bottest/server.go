@@ -0,0 +1,194 @@
+package bottest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// Token is the bot token bots of a Server use.
+const Token = "123456:test"
+
+// Call is a request a bot made to the Bot API. Params hold the request
+// fields as strings, files by their file names.
+type Call struct {
+	Method string
+	Params map[string]string
+}
+
+// Server is a fake Bot API. It accepts every request, records it and
+// answers with a plausible result: sent and edited messages get increasing
+// message IDs, other methods return true.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	calls     []Call
+	errors    []error
+	messageID int
+}
+
+// NewServer starts a fake Bot API. Close it when done.
+func NewServer() *Server {
+	s := &Server{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// NewBot returns a bot talking to the server. It handles updates passed to
+// ProcessUpdate synchronously, so their calls are recorded once it returns.
+// Errors of handlers are available through Errors.
+func (s *Server) NewBot() (*tele.Bot, error) {
+	return tele.NewBot(tele.Settings{
+		URL:         s.URL,
+		Token:       Token,
+		Synchronous: true,
+		OnError: func(err error, c tele.Context) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.errors = append(s.errors, err)
+		},
+	})
+}
+
+// Calls returns the recorded calls of the given methods, or all calls
+// without methods.
+func (s *Server) Calls(methods ...string) []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var calls []Call
+	for _, call := range s.calls {
+		if len(methods) == 0 || contains(methods, call.Method) {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Errors returns the errors handlers returned so far.
+func (s *Server) Errors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]error(nil), s.errors...)
+}
+
+// Reset forgets the recorded calls and errors.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = nil
+	s.errors = nil
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+	params, err := readParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.calls = append(s.calls, Call{Method: method, Params: params})
+	result := s.result(method, params)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+func (s *Server) result(method string, params map[string]string) interface{} {
+	switch {
+	case method == "getMe":
+		return map[string]interface{}{"id": 1, "is_bot": true, "first_name": "Test", "username": "test_bot"}
+	case method == "getFile":
+		return map[string]interface{}{"file_id": params["file_id"], "file_path": "files/" + params["file_id"]}
+	case method == "sendChatAction":
+		return true
+	case strings.HasPrefix(method, "send"), strings.HasPrefix(method, "edit"),
+		method == "forwardMessage", method == "copyMessage":
+		// Inline messages have no chat, editing them returns true.
+		if params["chat_id"] == "" {
+			return true
+		}
+
+		chatID, _ := strconv.ParseInt(params["chat_id"], 10, 64)
+		messageID, _ := strconv.Atoi(params["message_id"])
+		if messageID == 0 {
+			s.messageID++
+			messageID = s.messageID
+		}
+
+		text := params["text"]
+		if text == "" {
+			text = params["caption"]
+		}
+		return map[string]interface{}{
+			"message_id": messageID,
+			"date":       time.Now().Unix(),
+			"chat":       map[string]interface{}{"id": chatID, "type": "private"},
+			"text":       text,
+		}
+	default:
+		return true
+	}
+}
+
+// readParams reads the fields of a JSON or multipart request.
+func readParams(r *http.Request) (map[string]string, error) {
+	params := make(map[string]string)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, err
+		}
+		for key, values := range r.MultipartForm.Value {
+			params[key] = values[0]
+		}
+		for key, files := range r.MultipartForm.File {
+			params[key] = files[0].Filename
+		}
+		return params, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&fields); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	for key, value := range fields {
+		if str, ok := value.(string); ok {
+			params[key] = str
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", key, err)
+		}
+		params[key] = string(encoded)
+	}
+	return params, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package bot
+
+import (
+	"bytes"
+	"log"
+	"strconv"
+	"time"
+
+	"backend/internal/chart"
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/report"
+	"backend/internal/timeout"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const maxChartDays = 90
+
+func (h *BotHandler) HandleChart(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	days := 14
+	args := c.Args()
+	if len(args) > 0 {
+		var err error
+		days, err = strconv.Atoi(args[0])
+		if err != nil || days <= 0 || days > maxChartDays {
+			return c.Send(p.Sprintf("Usage: /chart [days]\nDays must be between 1 and %d", maxChartDays))
+		}
+	}
+
+	userID := identity.From(c)
+
+	prefs := h.preferences(ctx, userID)
+	now := time.Now().In(prefs.Location())
+	period := report.LastDays(now, prefs.Noon, days)
+
+	totals, err := h.db.GetDailyTotals(ctx, userID, period[0].Start, now, prefs.Location().String(), prefs.Noon)
+	if err != nil {
+		log.Printf("Error getting daily totals: %v", err)
+		return c.Send(p.Sprintf("❌ Error fetching records: %v", err))
+	}
+
+	points := make([]chart.Day, len(period))
+	for i, day := range period {
+		points[i].Label = day.Label()
+		for _, total := range totals {
+			if total.Day.Format("2006-01-02") == day.Start.Format("2006-01-02") {
+				points[i].Nutrition = total.Nutrition
+			}
+		}
+	}
+
+	image, err := chart.Render(points, float64(prefs.GoalKcal))
+	if err != nil {
+		log.Printf("Error rendering chart: %v", err)
+		return c.Send(p.Sprintf("❌ Error rendering chart: %v", err))
+	}
+
+	photo := &tele.Photo{
+		File:    tele.FromReader(bytes.NewReader(image)),
+		Caption: p.Plural(days, "📈 Calories and macros for the last day", "📈 Calories and macros for the last %d days"),
+	}
+	return c.Send(photo)
+}
@@ -0,0 +1,120 @@
+package conversation
+
+import (
+	"sync"
+	"time"
+
+	"backend/internal/i18n"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// Timeout is how long a conversation waits for the user's next message.
+const Timeout = 10 * time.Minute
+
+// Step handles the next text message of a user in a multi-step flow.
+type Step func(c tele.Context) error
+
+type pending struct {
+	step    Step
+	expires time.Time
+}
+
+// Conversations keeps the pending step of every user who is in the middle
+// of a multi-step flow, e.g. asked for an amount after picking a product.
+type Conversations struct {
+	mu      sync.Mutex
+	steps   map[int64]pending
+	cancels []func(userID int64) bool
+
+	// Fallback handles text messages outside of a conversation. Such
+	// messages are ignored when it is nil.
+	Fallback Step
+}
+
+func New() *Conversations {
+	return &Conversations{steps: make(map[int64]pending)}
+}
+
+// Expect makes step handle the next text message of the user, replacing
+// any step the user was waiting on.
+func (cv *Conversations) Expect(userID int64, step Step) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	cv.steps[userID] = pending{step: step, expires: time.Now().Add(Timeout)}
+}
+
+// Cancel drops the pending step of the user and reports whether there was one.
+func (cv *Conversations) Cancel(userID int64) bool {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	p, ok := cv.steps[userID]
+	delete(cv.steps, userID)
+	return ok && time.Now().Before(p.expires)
+}
+
+// OnCancel makes /cancel also call fn, which drops any other state the user
+// is in the middle of, such as a pick offered by buttons, and reports
+// whether there was some.
+func (cv *Conversations) OnCancel(fn func(userID int64) bool) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	cv.cancels = append(cv.cancels, fn)
+}
+
+// take removes and returns the pending step of the user.
+func (cv *Conversations) take(userID int64) Step {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	p, ok := cv.steps[userID]
+	if !ok {
+		return nil
+	}
+
+	delete(cv.steps, userID)
+	if time.Now().After(p.expires) {
+		return nil
+	}
+
+	return p.step
+}
+
+// HandleText passes a text message to the sender's pending step. Messages
+// outside of a conversation go to Fallback.
+func (cv *Conversations) HandleText(c tele.Context) error {
+	step := cv.take(c.Sender().ID)
+	if step == nil {
+		if cv.Fallback != nil {
+			return cv.Fallback(c)
+		}
+		return nil
+	}
+
+	return step(c)
+}
+
+// HandleCancel drops the sender's pending step and whatever the OnCancel
+// hooks keep for them.
+func (cv *Conversations) HandleCancel(c tele.Context) error {
+	p := i18n.From(c)
+	cancelled := cv.Cancel(c.Sender().ID)
+
+	cv.mu.Lock()
+	cancels := cv.cancels
+	cv.mu.Unlock()
+	for _, cancel := range cancels {
+		if cancel(c.Sender().ID) {
+			cancelled = true
+		}
+	}
+
+	if !cancelled {
+		return c.Send(p.T("Nothing to cancel"))
+	}
+
+	return c.Send(p.T("❌ Cancelled"))
+}
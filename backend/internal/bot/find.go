@@ -0,0 +1,139 @@
+package bot
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/models"
+	"backend/internal/timeout"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+const findLimit = 8
+
+func (h *BotHandler) HandleFind(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	query := strings.TrimSpace(c.Message().Payload)
+	if query == "" {
+		return c.Send(p.T("Usage: /find <query>\nExample: /find chicken"))
+	}
+
+	userID := identity.From(c)
+
+	products, err := h.db.SearchProductsByName(ctx, userID, query, findLimit)
+	if err != nil {
+		log.Printf("Error searching products: %v", err)
+		return c.Send(p.Sprintf("❌ Error searching products: %v", err))
+	}
+
+	if len(products) == 0 {
+		return c.Send(p.Sprintf("Nothing found for \"%s\"", query))
+	}
+
+	menu := &tele.ReplyMarkup{}
+	var rows []tele.Row
+	for _, product := range products {
+		btn := menu.Data(productLabel(p, product), h.BtnFindPick.Unique, product.UUID.String())
+		rows = append(rows, menu.Row(btn))
+	}
+	menu.Inline(rows...)
+
+	return c.Send(p.Sprintf("🔎 Results for \"%s\":", query), menu)
+}
+
+func (h *BotHandler) HandleFindPick(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	productUUID, err := uuid.Parse(c.Callback().Data)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Invalid product")})
+	}
+
+	userID := identity.From(c)
+
+	product, err := h.db.GetProductByUUID(ctx, productUUID)
+	if err != nil || (product.Owner != nil && *product.Owner != userID) {
+		if err != nil {
+			log.Printf("Error getting product %s: %v", productUUID, err)
+		}
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Product not found")})
+	}
+
+	h.askAmount(c, userID, product)
+
+	if err := c.Respond(); err != nil {
+		log.Printf("Error answering callback: %v", err)
+	}
+	return c.Send(amountPrompt(p, product))
+}
+
+// askAmount waits for the user to send how much of product they ate and
+// records it.
+func (h *BotHandler) askAmount(c tele.Context, userID int64, product *models.ProductDetails) {
+	var step func(c tele.Context) error
+	step = func(c tele.Context) error {
+		p := i18n.From(c)
+		ctx := timeout.From(c)
+		prefs := h.preferences(ctx, userID)
+		fields := strings.Fields(c.Text())
+		when, fields, err := models.ParseWhen(fields, time.Now().In(prefs.Location()), prefs.Noon)
+		var amount float64
+		var unit string
+		if err == nil {
+			amount, unit, err = models.ParseAmount(strings.Join(fields, ""))
+		}
+		if err != nil {
+			err = errors.New(p.T(err.Error()))
+		} else {
+			amount, err = productAmount(p, product, amount, unit)
+		}
+		if err != nil {
+			h.conversations.Expect(c.Sender().ID, step)
+			return c.Send(err.Error() + "\n" + amountPrompt(p, product))
+		}
+
+		record, err := h.insertRecord(ctx, userID, product.UUID, amount, "", when)
+		if err != nil {
+			log.Printf("Error inserting record: %v", err)
+			return c.Send(p.Sprintf("❌ Error creating record: %v", err))
+		}
+
+		return h.sendRecorded(c, product, record)
+	}
+
+	h.conversations.Expect(c.Sender().ID, step)
+}
+
+func amountPrompt(p *i18n.Printer, product *models.ProductDetails) string {
+	example := "250g"
+	switch product.Unit {
+	case models.UnitMilliliter:
+		example = "300ml"
+	case models.UnitPiece:
+		example = p.T("1 or 2pcs")
+	}
+
+	return p.Sprintf("How much %s did you eat? Send an amount like %s, optionally with a time like -2h (/cancel to stop)", product.Name, example)
+}
+
+// productLabel describes a product with its energy per 100 g/ml or piece.
+func productLabel(p *i18n.Printer, product *models.ProductDetails) string {
+	per := "100 " + p.T(product.Unit)
+	if product.Unit == models.UnitPiece {
+		per = p.T("pc")
+	}
+
+	name := product.Name
+	if product.Brand != "" {
+		name += " (" + product.Brand + ")"
+	}
+
+	return p.Sprintf("🍽️ %s · %d kcal/%s", name, product.Ccal, per)
+}
@@ -1,27 +1,58 @@
 package bot
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"html"
 	"log"
 	"strconv"
 	"strings"
 	"time"
 
-	"backend/internal/database"
+	"backend/internal/bot/conversation"
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/models"
+	"backend/internal/report"
+	"backend/internal/storage"
+	"backend/internal/timeout"
 
+	"github.com/google/uuid"
 	tele "gopkg.in/telebot.v3"
 )
 
 type BotHandler struct {
-	db *database.DB
+	db            storage.Store
+	conversations *conversation.Conversations
+	BtnFindPick   tele.Btn
+
+	BtnEditRecord   tele.Btn
+	BtnDeleteRecord tele.Btn
+}
+
+func NewBotHandler(db storage.Store, conversations *conversation.Conversations) *BotHandler {
+	menu := &tele.ReplyMarkup{}
+	return &BotHandler{
+		db:            db,
+		conversations: conversations,
+		BtnFindPick:   menu.Data("", "find_pick"),
+
+		BtnEditRecord:   menu.Data("✏️ Edit", "record_edit"),
+		BtnDeleteRecord: menu.Data("🗑 Delete", "record_delete"),
+	}
 }
 
-func NewBotHandler(db *database.DB) *BotHandler {
-	return &BotHandler{db: db}
+// StoredLang returns the language the user chose with /set_lang, or an
+// empty string. It backs the i18n middleware.
+func (h *BotHandler) StoredLang(ctx context.Context, user *tele.User) string {
+	return h.preferences(ctx, user.ID).Lang
 }
 
 func (h *BotHandler) HandleStart(c tele.Context) error {
-	return c.Send("Welcome to C-Meter! 👋\n\nUse /help to see available commands.")
+	p := i18n.From(c)
+	return c.Send(p.T("Welcome to C-Meter! 👋\n\nUse /help to see available commands."))
 }
 
 func (h *BotHandler) HandleHelp(c tele.Context) error {
@@ -30,211 +61,520 @@ func (h *BotHandler) HandleHelp(c tele.Context) error {
 /start - Start the bot
 /help - Show this help message
 /ping - Check database connection and schema version
-/get [days] - Get your entries (default: 1 day)
-/today - Get today's entries
-/record <name> <ccal> [proteins] [fats] [carbs] - Add a food record
+/get [days] - Get your entries for the last N days (default: 1 day)
+/today - Get today's entries (since your day flip time)
+/stats [week|month|N] - Show averages, best/worst day and streaks
+/chart [days] - Chart of daily calories and macros (default: 14 days)
+/record <name> [amount] [ccal] [proteins] [fats] [carbs] [#meal] [when] - Add a food record (amount: 250g, 300ml, 2pcs; nutrition only the first time; meal: #lunch; when: yesterday 20:30, -2h, @08:15)
+/find <query> - Find a product in the catalog and record it
+Send a barcode number or a photo of a barcode to find a packaged product and record it
+/recipe - Create home-cooked recipes from catalog products and list them
+/import - Add products from a CSV or JSON file
+/export [csv|json] - Download your products as a file
+/edit <id> <amount|kcal> - Change the amount of a record (e.g. 200g or 350kcal)
+/delete <id> - Delete a record
+/cancel - Cancel the current dialog
+/notify - Daily summary and meal reminder settings
 /set_noon <HH:MM> - Set your day flip time (default: 00:00)
-/set_lang <lang> - Set your language (ru/en)`
+/set_goal <kcal> [proteins] [fats] [carbs] - Set your daily goals (/set_goal off to clear)
+/set_lang <lang> - Set your language (ru/en)
+/set_tz <timezone> - Set your timezone (e.g. Europe/Berlin)`
 
-	return c.Send(helpText)
+	return c.Send(i18n.From(c).T(helpText))
 }
 
 func (h *BotHandler) HandlePing(c tele.Context) error {
-	version, err := h.db.GetLatestSchemaVersion()
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	version, err := h.db.GetLatestSchemaVersion(ctx)
 	if err != nil {
 		log.Printf("Error getting schema version: %v", err)
-		return c.Send("❌ Database error: " + err.Error())
+		return c.Send(p.Sprintf("❌ Database error: %v", err))
 	}
 
 	if version == "" {
-		return c.Send("✅ Database connected\n📦 No migrations applied yet")
+		return c.Send(p.T("✅ Database connected\n📦 No migrations applied yet"))
 	}
 
-	message := fmt.Sprintf("✅ Database connected\n📦 Schema version: %s", version)
+	message := p.Sprintf("✅ Database connected\n📦 Schema version: %s", version)
 	return c.Send(message)
 }
 
+// maxGetDays is the longest period /get lists. Longer ones would not fit in
+// a message anyway.
+const maxGetDays = 31
+
 func (h *BotHandler) HandleGet(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
 	args := c.Args()
 	days := 1
 	
 	if len(args) > 0 {
 		var err error
 		days, err = strconv.Atoi(args[0])
-		if err != nil || days <= 0 {
-			return c.Send("Please provide a valid number of days (positive integer)")
+		if err != nil || days <= 0 || days > maxGetDays {
+			return c.Send(p.Sprintf("Usage: /get [days]\nDays must be between 1 and %d", maxGetDays))
 		}
 	}
 
-	login := c.Sender().Username
-	if login == "" {
-		login = fmt.Sprintf("user_%d", c.Sender().ID)
-	}
+	userID := identity.From(c)
 
-	endTime := time.Now()
-	startTime := endTime.Add(-time.Duration(days) * 24 * time.Hour)
+	prefs := h.preferences(ctx, userID)
+	now := time.Now().In(prefs.Location())
+	period := report.LastDays(now, prefs.Noon, days)
 
-	records, err := h.db.GetRecordsByLoginAndTimeRange(login, startTime, endTime)
+	records, err := h.db.GetRecordsByUserAndTimeRange(ctx, userID, period[0].Start, now)
 	if err != nil {
 		log.Printf("Error getting records: %v", err)
-		return c.Send("❌ Error fetching records: " + err.Error())
+		return c.Send(p.Sprintf("❌ Error fetching records: %v", err))
 	}
 
 	if len(records) == 0 {
-		return c.Send("No records found for the last " + strconv.Itoa(days) + " days")
+		return c.Send(p.Plural(days, "No records found for the last day", "No records found for the last %d days"))
+	}
+
+	var entries []report.Entry
+	for _, record := range records {
+		product, err := h.db.GetProductByUUID(ctx, record.ProductUUID)
+		if err != nil {
+			log.Printf("Error getting product %s: %v", record.ProductUUID, err)
+			continue
+		}
+
+		createdAt := record.CreatedAt.In(now.Location())
+		meal := record.Meal
+		if meal == "" {
+			meal = models.InferMeal(createdAt, prefs.Noon)
+		}
+
+		entries = append(entries, report.Entry{
+			Time:      createdAt,
+			Name:      product.Name,
+			Meal:      meal,
+			Nutrition: record.Nutrition(),
+		})
 	}
 
 	var result strings.Builder
 	if days == 1 {
-		result.WriteString("<b>Today's records:</b>\n\n")
+		result.WriteString(p.T("<b>Today's records:</b>\n\n"))
 	} else {
-		result.WriteString(fmt.Sprintf("<b>Records for last %d days:</b>\n\n", days))
+		result.WriteString(p.Plural(days, "<b>Records for the last day:</b>\n\n", "<b>Records for the last %d days:</b>\n\n"))
 	}
-	
-	result.WriteString("<pre>")
-	result.WriteString("date & time │      name       │ kcal\n")
-	result.WriteString("────────────┼─────────────────┼─────\n")
-
-	totalKcal := int64(0)
-	for i := len(records) - 1; i >= 0; i-- {
-		record := records[i]
-		
-		product, err := h.db.GetProductByUUID(record.ProductUUID)
-		if err != nil {
-			log.Printf("Error getting product %s: %v", record.ProductUUID, err)
+
+	var total models.Nutrition
+	loggedDays := 0
+	for _, group := range report.Group(period, entries) {
+		if len(group.Entries) == 0 {
 			continue
 		}
+		loggedDays++
 
-		dateTime := record.CreatedAt.Format("02-01 15:04")
-		name := product.Name
-		if len(name) > 15 {
-			name = name[:15]
+		result.WriteString("<pre>")
+		if days > 1 {
+			result.WriteString(fmt.Sprintf("📅 %s\n", group.Day.Label()))
 		}
-		
-		padding := (15 - len(name)) / 2
-		centeredName := fmt.Sprintf("%*s%s%*s", padding, "", name, 15-len(name)-padding, "")
-		
-		ccal := product.Ccal * record.Amount
-		totalKcal += ccal
+		result.WriteString(html.EscapeString(report.Table(p, group.Entries)))
+		result.WriteString("</pre>\n")
 
-		line := fmt.Sprintf("%s │ %s │ %-4d\n", dateTime, centeredName, ccal)
-		result.WriteString(line)
+		total = total.Add(group.Total())
 	}
-	
-	result.WriteString("</pre>")
-	
+
+	result.WriteString(p.Sprintf("\n📋 <b>Total: %s</b>", report.FormatTotal(p, total)))
+	if split := report.EnergySplit(p, total); split != "" {
+		result.WriteString(p.T("\n⚡ Energy: ") + split)
+	}
+
 	if days == 1 {
-		result.WriteString(fmt.Sprintf("\n\n📋 <b>Total: %d kcal</b>", totalKcal))
+		if meals := report.MealBreakdown(p, entries); meals != "" {
+			result.WriteString(p.T("\n\n🍽 <b>Meals</b>\n") + "<pre>" + meals + "</pre>")
+		}
+		if progress := report.GoalProgress(p, total, prefs); progress != "" {
+			result.WriteString(p.T("\n\n🎯 <b>Goals</b>\n") + "<pre>" + progress + "</pre>")
+		}
+	} else if loggedDays > 0 {
+		// Like /stats, average over the days with records only.
+		n := float64(loggedDays)
+		average := models.Nutrition{
+			Kcal:     total.Kcal / n,
+			Proteins: total.Proteins / n,
+			Fats:     total.Fats / n,
+			Carbs:    total.Carbs / n,
+		}
+		result.WriteString(p.Sprintf("\n📊 Daily average: %s", report.FormatTotal(p, average)))
 	}
 
 	return c.Send(result.String(), &tele.SendOptions{ParseMode: tele.ModeHTML})
 }
 
+// preferences returns the user's stored preferences or the defaults when the
+// user has not set any.
+func (h *BotHandler) preferences(ctx context.Context, userID int64) *models.UserPreferences {
+	return storage.UserPreferences(ctx, h.db, userID)
+}
+
+// insertRecord stores a record eaten at when, or now for a zero time. The
+// meal is inferred from that time unless given.
+func (h *BotHandler) insertRecord(ctx context.Context, userID int64, productUUID uuid.UUID, amount float64, meal string, when time.Time) (*models.Record, error) {
+	return storage.InsertRecord(ctx, h.db, userID, productUUID, amount, meal, when)
+}
+
+const recordUsage = "Usage: /record <name> [amount] [ccal] [proteins] [fats] [carbs] [#meal] [when]\n" +
+	"Amount is like 250g, 300ml or 2pcs. Nutrition is per 100 g/ml, or per piece when no amount is given. " +
+	"Nutrition is only needed the first time you record a product. " +
+	"The meal (#breakfast, #lunch, #dinner, #snack) is guessed from the time unless given. " +
+	"When is now unless given as yesterday 20:30, -2h or @08:15.\n" +
+	"Example: /record Chicken Breast 250g 165 31 3 0"
+
 func (h *BotHandler) HandleRecord(c tele.Context) error {
-	args := c.Args()
-	if len(args) < 2 {
-		return c.Send("Usage: /record <name> <ccal> [proteins] [fats] [carbs]\nExample: /record \"Chicken Breast\" 165 31 3 0")
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+
+	userID := identity.From(c)
+
+	prefs := h.preferences(ctx, userID)
+	req, err := parseRecordArgs(p, c.Args(), time.Now().In(prefs.Location()), prefs.Noon)
+	if err != nil {
+		return c.Send(err.Error())
 	}
 
-	name := args[0]
-	
-	ccal, err := strconv.ParseInt(args[1], 10, 64)
-	if err != nil || ccal <= 0 {
-		return c.Send("Calories must be a positive number")
+	var product *models.ProductDetails
+	if req.hasNutrition {
+		product, err = h.saveProduct(ctx, p, userID, req)
+		if err != nil {
+			log.Printf("Error saving product: %v", err)
+			return c.Send(p.Sprintf("❌ Error creating product: %v", err))
+		}
+	} else {
+		product, err = h.db.GetProductByName(ctx, userID, req.name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Send(h.unknownProductMessage(ctx, p, userID, req.name))
+		}
+		if err != nil {
+			log.Printf("Error finding product: %v", err)
+			return c.Send(p.Sprintf("❌ Error finding product: %v", err))
+		}
 	}
 
-	var proteins, fats, carbs int64
+	amount, err := productAmount(p, product, req.amount, req.unit)
+	if err != nil {
+		return c.Send(err.Error())
+	}
 
-	if len(args) > 2 {
-		proteins, err = strconv.ParseInt(args[2], 10, 64)
-		if err != nil || proteins < 0 {
-			return c.Send("Proteins must be a non-negative number")
-		}
+	record, err := h.insertRecord(ctx, userID, product.UUID, amount, req.meal, req.when)
+	if err != nil {
+		log.Printf("Error inserting record: %v", err)
+		return c.Send(p.Sprintf("❌ Error creating record: %v", err))
 	}
 
-	if len(args) > 3 {
-		fats, err = strconv.ParseInt(args[3], 10, 64)
-		if err != nil || fats < 0 {
-			return c.Send("Fats must be a non-negative number")
+	return h.sendRecorded(c, product, record)
+}
+
+// sendRecorded confirms a new record with buttons to edit or delete it.
+func (h *BotHandler) sendRecorded(c tele.Context, product *models.ProductDetails, record *models.Record) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(menu.Row(
+		menu.Data(p.T(h.BtnEditRecord.Text), h.BtnEditRecord.Unique, record.UUID.String()),
+		menu.Data(p.T(h.BtnDeleteRecord.Text), h.BtnDeleteRecord.Unique, record.UUID.String()),
+	))
+
+	loc := h.preferences(ctx, record.UserID).Location()
+	return c.Send(recordedMessage(p, product, record, loc), menu)
+}
+
+func recordedMessage(p *i18n.Printer, product *models.ProductDetails, record *models.Record, loc *time.Location) string {
+	nutrition := record.Nutrition()
+	return p.Sprintf("✅ Recorded: %s (%s)\n📊 Calories: %.0f\n🍽 Meal: %s · 🕒 %s\nID: %s",
+		product.Name, models.FormatAmount(record.Amount, p.T(record.Unit)), nutrition.Kcal,
+		p.T(record.Meal), record.CreatedAt.In(loc).Format("02-01 15:04"), record.UUID)
+}
+
+// saveProduct stores the nutrition given in /record in the user's own
+// catalog entry, creating or updating it as needed. Past records keep the
+// nutrition they were recorded with.
+func (h *BotHandler) saveProduct(ctx context.Context, p *i18n.Printer, userID int64, req *recordArgs) (*models.ProductDetails, error) {
+	unit := req.unit
+	if unit == "" {
+		unit = models.UnitPiece
+	}
+
+	product, created, err := h.db.GetOrCreateProduct(ctx, userID, req.name, req.ccal, req.fats, req.proteins, req.carbs, unit)
+	if err != nil || created {
+		return product, err
+	}
+
+	if product.Ccal == req.ccal && product.Fats == req.fats && product.Proteins == req.proteins &&
+		product.Carbs == req.carbs && product.Unit == unit {
+		return product, nil
+	}
+
+	_, err = h.db.GetRecipe(ctx, userID, product.UUID)
+	if err == nil {
+		return nil, errors.New(p.Sprintf("%s is a recipe, its nutrition comes from the ingredients (/recipe edit %s)", product.Name, product.Name))
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	product, err = h.db.UpdateProduct(ctx, userID, product.UUID, product.Name, req.ccal, req.fats, req.proteins, req.carbs, unit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := storage.RefreshRecipes(ctx, h.db, userID); err != nil {
+		log.Printf("Error refreshing recipes: %v", err)
+	}
+	return product, nil
+}
+
+func (h *BotHandler) unknownProductMessage(ctx context.Context, p *i18n.Printer, userID int64, name string) string {
+	var message strings.Builder
+	message.WriteString(p.Sprintf("🤷 \"%s\" is not in your catalog yet.\n", name))
+	message.WriteString(p.Sprintf("Record it once with its nutrition: /record %s 100g <ccal> [proteins] [fats] [carbs]", name))
+
+	similar, err := h.db.SearchProductsByName(ctx, userID, name, 5)
+	if err != nil {
+		log.Printf("Error searching products: %v", err)
+	}
+	if len(similar) > 0 {
+		message.WriteString(p.T("\n\nSimilar products:"))
+		for _, product := range similar {
+			message.WriteString("\n• " + product.Name)
 		}
 	}
 
-	if len(args) > 4 {
-		carbs, err = strconv.ParseInt(args[4], 10, 64)
-		if err != nil || carbs < 0 {
-			return c.Send("Carbs must be a non-negative number")
+	return message.String()
+}
+
+// productAmount resolves the amount to record for a product. Without an
+// explicit amount a single piece or 100 g/ml is recorded.
+func productAmount(p *i18n.Printer, product *models.ProductDetails, amount float64, unit string) (float64, error) {
+	if amount == 0 {
+		if product.Unit == models.UnitPiece {
+			return 1, nil
 		}
+		return 100, nil
+	}
+
+	if unit != "" && unit != product.Unit {
+		return 0, errors.New(p.Sprintf("%s is measured in %s, not %s", product.Name, p.T(product.Unit), p.T(unit)))
 	}
 
-	product, err := h.db.InsertProduct(name, ccal, fats, proteins, carbs)
+	return amount, nil
+}
+
+// recordArgs is a parsed /record command. A zero amount means no amount
+// was given.
+type recordArgs struct {
+	name         string
+	amount       float64
+	unit         string
+	meal         string
+	when         time.Time
+	hasNutrition bool
+	ccal         int64
+	proteins     int64
+	fats         int64
+	carbs        int64
+}
+
+// parseRecordArgs parses "<name> [amount] [ccal] [proteins] [fats] [carbs]"
+// with an optional meal override such as "#lunch" and a time qualifier such
+// as "yesterday 20:30", "-2h" or "@08:15" anywhere in the arguments.
+// The name may span several words and ends at the first numeric argument.
+// Amounts must carry a unit so they are not mistaken for calories.
+func parseRecordArgs(p *i18n.Printer, args []string, now time.Time, flip time.Time) (*recordArgs, error) {
+	req := &recordArgs{}
+
+	var err error
+	req.when, args, err = models.ParseWhen(args, now, flip)
 	if err != nil {
-		log.Printf("Error inserting product: %v", err)
-		return c.Send("❌ Error creating product: " + err.Error())
+		return nil, errors.New(p.T(err.Error()))
 	}
 
-	login := c.Sender().Username
-	if login == "" {
-		login = fmt.Sprintf("user_%d", c.Sender().ID)
+	var rest []string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "#") {
+			meal, ok := models.ParseMeal(arg)
+			if !ok {
+				return nil, errors.New(p.Sprintf("Unknown meal %s. Use #%s", arg, strings.Join(models.Meals, ", #")))
+			}
+			req.meal = meal
+			continue
+		}
+		rest = append(rest, arg)
 	}
+	args = rest
 
-	record, err := h.db.InsertRecord(product.UUID, 1, login)
-	if err != nil {
-		log.Printf("Error inserting record: %v", err)
-		return c.Send("❌ Error creating record: " + err.Error())
+	var nameParts []string
+	for len(args) > 0 {
+		if _, _, err := models.ParseAmount(args[0]); err == nil {
+			break
+		}
+		nameParts = append(nameParts, args[0])
+		args = args[1:]
 	}
+	req.name = strings.Trim(strings.Join(nameParts, " "), "\"'")
 
-	message := fmt.Sprintf("✅ Recorded: %s\n📊 Calories: %d\nID: %s", name, ccal, record.UUID)
-	return c.Send(message)
+	if req.name == "" {
+		return nil, errors.New(p.T(recordUsage))
+	}
+
+	if len(args) > 0 {
+		if amount, unit, err := models.ParseAmount(args[0]); err == nil && unit != "" {
+			req.amount = amount
+			req.unit = unit
+			args = args[1:]
+		}
+	}
+
+	if len(args) == 0 {
+		return req, nil
+	}
+	req.hasNutrition = true
+
+	req.ccal, err = strconv.ParseInt(args[0], 10, 64)
+	if err != nil || req.ccal <= 0 {
+		return nil, errors.New(p.T("Calories must be a positive number"))
+	}
+
+	if len(args) > 1 {
+		req.proteins, err = strconv.ParseInt(args[1], 10, 64)
+		if err != nil || req.proteins < 0 {
+			return nil, errors.New(p.T("Proteins must be a non-negative number"))
+		}
+	}
+
+	if len(args) > 2 {
+		req.fats, err = strconv.ParseInt(args[2], 10, 64)
+		if err != nil || req.fats < 0 {
+			return nil, errors.New(p.T("Fats must be a non-negative number"))
+		}
+	}
+
+	if len(args) > 3 {
+		req.carbs, err = strconv.ParseInt(args[3], 10, 64)
+		if err != nil || req.carbs < 0 {
+			return nil, errors.New(p.T("Carbs must be a non-negative number"))
+		}
+	}
+
+	return req, nil
 }
 
 func (h *BotHandler) HandleSetNoon(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
 	args := c.Args()
 	if len(args) == 0 {
-		return c.Send("Usage: /set_noon <HH:MM>\nExample: /set_noon 03:00")
+		return c.Send(p.T("Usage: /set_noon <HH:MM>\nExample: /set_noon 03:00"))
 	}
 
 	timeStr := args[0] + ":00"
 	noonTime, err := time.Parse("15:04:05", timeStr)
 	if err != nil {
-		return c.Send("Invalid time format. Use HH:MM (e.g., 03:00)")
+		return c.Send(p.T("Invalid time format. Use HH:MM (e.g., 03:00)"))
 	}
 
-	login := c.Sender().Username
-	if login == "" {
-		login = fmt.Sprintf("user_%d", c.Sender().ID)
-	}
+	userID := identity.From(c)
 
-	err = h.db.UpsertUserNoon(login, noonTime)
+	err = h.db.UpsertUserNoon(ctx, userID, noonTime)
 	if err != nil {
 		log.Printf("Error setting noon: %v", err)
-		return c.Send("❌ Error setting day flip time: " + err.Error())
+		return c.Send(p.Sprintf("❌ Error setting day flip time: %v", err))
 	}
 
-	return c.Send(fmt.Sprintf("✅ Day flip time set to %s", args[0]))
+	return c.Send(p.Sprintf("✅ Day flip time set to %s", args[0]))
 }
 
 func (h *BotHandler) HandleSetLang(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
 	args := c.Args()
 	if len(args) == 0 {
-		return c.Send("Usage: /set_lang <lang>\nExample: /set_lang ru\nSupported: ru, en")
+		return c.Send(p.Sprintf("Usage: /set_lang <lang>\nExample: /set_lang ru\nSupported: %s", strings.Join(i18n.Languages, ", ")))
 	}
 
-	lang := args[0]
-	if lang != "ru" && lang != "en" {
-		return c.Send("Unsupported language. Available: ru, en")
+	lang := strings.ToLower(args[0])
+	if !i18n.Supported(lang) {
+		return c.Send(p.Sprintf("Unsupported language. Available: %s", strings.Join(i18n.Languages, ", ")))
 	}
 
-	login := c.Sender().Username
-	if login == "" {
-		login = fmt.Sprintf("user_%d", c.Sender().ID)
-	}
+	userID := identity.From(c)
 
-	err := h.db.UpsertUserLang(login, lang)
+	err := h.db.UpsertUserLang(ctx, userID, lang)
 	if err != nil {
 		log.Printf("Error setting language: %v", err)
-		return c.Send("❌ Error setting language: " + err.Error())
+		return c.Send(p.Sprintf("❌ Error setting language: %v", err))
 	}
 
-	return c.Send(fmt.Sprintf("✅ Language set to %s", lang))
+	// Confirm in the language just chosen.
+	return c.Send(i18n.New(lang).Sprintf("✅ Language set to %s", lang))
 }
 
+func (h *BotHandler) HandleSetTimezone(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	args := c.Args()
+	if len(args) == 0 {
+		return c.Send(p.T("Usage: /set_tz <timezone>\nExample: /set_tz Europe/Berlin\nUse IANA timezone names"))
+	}
+
+	timezone := args[0]
+	loc, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" || timezone == "Local" {
+		return c.Send(p.T("Unknown timezone. Use an IANA name such as Europe/Moscow or America/New_York"))
+	}
+
+	userID := identity.From(c)
+
+	err = h.db.UpsertUserTimezone(ctx, userID, loc.String())
+	if err != nil {
+		log.Printf("Error setting timezone: %v", err)
+		return c.Send(p.Sprintf("❌ Error setting timezone: %v", err))
+	}
+
+	return c.Send(p.Sprintf("✅ Timezone set to %s\n🕒 Local time: %s", loc, time.Now().In(loc).Format("02-01 15:04")))
+}
+
+func (h *BotHandler) HandleSetGoal(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	args := c.Args()
+	if len(args) == 0 {
+		return c.Send(p.T("Usage: /set_goal <kcal> [proteins] [fats] [carbs]\nExample: /set_goal 2000 120 70 220\nUse /set_goal off to clear your goals"))
+	}
+
+	userID := identity.From(c)
+
+	goals := make([]int64, 4)
+	if !(len(args) == 1 && strings.EqualFold(args[0], "off")) {
+		if len(args) > len(goals) {
+			return c.Send(p.T("Too many values. Usage: /set_goal <kcal> [proteins] [fats] [carbs]"))
+		}
+		for i, arg := range args {
+			value, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil || value < 0 {
+				return c.Send(p.T("Goals must be non-negative numbers"))
+			}
+			goals[i] = value
+		}
+	}
+
+	err := h.db.UpsertUserGoals(ctx, userID, goals[0], goals[1], goals[2], goals[3])
+	if err != nil {
+		log.Printf("Error setting goals: %v", err)
+		return c.Send(p.Sprintf("❌ Error setting goals: %v", err))
+	}
+
+	if goals[0] == 0 && goals[1] == 0 && goals[2] == 0 && goals[3] == 0 {
+		return c.Send(p.T("✅ Daily goals cleared"))
+	}
+
+	return c.Send(p.Sprintf("✅ Daily goals set: %d kcal, proteins %d g, fats %d g, carbs %d g",
+		goals[0], goals[1], goals[2], goals[3]))
+}
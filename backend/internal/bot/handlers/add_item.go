@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"backend/internal/bot/conversation"
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/models"
+	"backend/internal/timeout"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+const productChoices = 6
+
+// itemDraft is a food item being added to the Locations tree that still
+// needs a product.
+type itemDraft struct {
+	userID int64
+	path   string
+	name   string
+}
+
+// HandleAddCallback asks what kind of node to add to the folder with the
+// given ID, or to the top level for an empty one.
+func (h *MenuHandler) HandleAddCallback(c tele.Context, folderID string) error {
+	p := i18n.From(c)
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(
+		menu.Row(
+			menu.Data(p.T("📁 Folder"), "addfolder:"+folderID),
+			menu.Data(p.T("🍽️ Food item"), "addfood:"+folderID),
+		),
+		menu.Row(menu.Data(p.T("⬅️ Back"), "nav:"+folderID)),
+	)
+
+	err := c.Edit(p.T("What do you want to add?"), menu)
+	if err != nil {
+		log.Printf("Error editing message: %v", err)
+	}
+
+	return c.Respond()
+}
+
+func (h *MenuHandler) HandleAddFolderCallback(c tele.Context, folderID string) error {
+	p := i18n.From(c)
+	userID := identity.From(c)
+	parentPath, err := h.folderPath(c, folderID)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	h.conversations.Expect(c.Sender().ID, func(c tele.Context) error {
+		name := strings.TrimSpace(c.Text())
+		if name == "" {
+			return c.Send(p.T("The name cannot be empty"))
+		}
+
+		if _, err := h.insertItem(timeout.From(c), userID, parentPath, name, nil); err != nil {
+			log.Printf("Error inserting common item: %v", err)
+			return c.Send(p.Sprintf("❌ Error adding folder: %v", err))
+		}
+
+		return h.showLocationsLevel(c, parentPath)
+	})
+
+	if err := c.Respond(); err != nil {
+		log.Printf("Error answering callback: %v", err)
+	}
+	return c.Send(p.T("📁 Send the folder name (/cancel to stop)"))
+}
+
+func (h *MenuHandler) HandleAddFoodCallback(c tele.Context, folderID string) error {
+	p := i18n.From(c)
+	userID := identity.From(c)
+	parentPath, err := h.folderPath(c, folderID)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	h.conversations.Expect(c.Sender().ID, func(c tele.Context) error {
+		name := strings.TrimSpace(c.Text())
+		if name == "" {
+			return c.Send(p.T("The name cannot be empty"))
+		}
+
+		draft := &itemDraft{userID: userID, path: parentPath, name: name}
+		return h.askProduct(c, draft, name)
+	})
+
+	if err := c.Respond(); err != nil {
+		log.Printf("Error answering callback: %v", err)
+	}
+	return c.Send(p.T("🍽️ Send the food item name (/cancel to stop)"))
+}
+
+// HandleAddProductCallback completes the pending draft with a product the
+// user picked from the catalog.
+func (h *MenuHandler) HandleAddProductCallback(c tele.Context, data string) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	productUUID, err := uuid.Parse(data)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Invalid product")})
+	}
+
+	userID := identity.From(c)
+
+	product, err := h.db.GetProductByUUID(ctx, productUUID)
+	if err != nil || (product.Owner != nil && *product.Owner != userID) {
+		if err != nil {
+			log.Printf("Error getting product %s: %v", productUUID, err)
+		}
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Product not found")})
+	}
+
+	h.mu.Lock()
+	draft := h.drafts[c.Sender().ID]
+	delete(h.drafts, c.Sender().ID)
+	h.mu.Unlock()
+
+	if draft == nil {
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Nothing to add, start again with ➕")})
+	}
+	h.conversations.Cancel(c.Sender().ID)
+
+	if _, err := h.insertItem(ctx, draft.userID, draft.path, draft.name, &product.UUID); err != nil {
+		log.Printf("Error inserting common item: %v", err)
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Error adding item")})
+	}
+
+	return h.showLocationsLevel(c, draft.path)
+}
+
+// askProduct offers catalog products matching query for the draft and waits
+// for either a pick, another query or the nutrition of a new product.
+func (h *MenuHandler) askProduct(c tele.Context, draft *itemDraft, query string) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	h.mu.Lock()
+	h.drafts[c.Sender().ID] = draft
+	h.mu.Unlock()
+
+	h.conversations.Expect(c.Sender().ID, h.productStep(draft))
+
+	products, err := h.db.SearchProductsByName(ctx, draft.userID, query, productChoices)
+	if err != nil {
+		log.Printf("Error searching products: %v", err)
+	}
+
+	text := p.Sprintf("Which product is \"%s\"?\n\n", draft.name) +
+		p.T("Pick one from the catalog, send another name to search, "+
+			"or send the nutrition of a new product: <ccal> [proteins] [fats] [carbs] [g|ml|pcs]\n"+
+			"Nutrition is per 100 g/ml, or per piece by default.")
+
+	if len(products) == 0 {
+		return c.Send(p.Sprintf("Nothing found for \"%s\".\n\n", query) + text)
+	}
+
+	menu := &tele.ReplyMarkup{}
+	var rows []tele.Row
+	for _, product := range products {
+		btnText := p.Sprintf("🍽️ %s · %d kcal", product.Name, product.Ccal)
+		rows = append(rows, menu.Row(menu.Data(btnText, "addprod:"+product.UUID.String())))
+	}
+	menu.Inline(rows...)
+
+	return c.Send(text, menu)
+}
+
+// productStep handles a text reply to askProduct: a number starts the
+// nutrition of a new product, anything else is a new catalog query.
+func (h *MenuHandler) productStep(draft *itemDraft) conversation.Step {
+	return func(c tele.Context) error {
+		p := i18n.From(c)
+		ctx := timeout.From(c)
+		text := strings.TrimSpace(c.Text())
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			return h.askProduct(c, draft, draft.name)
+		}
+
+		if _, err := strconv.ParseInt(fields[0], 10, 64); err != nil {
+			return h.askProduct(c, draft, text)
+		}
+
+		ccal, fats, proteins, carbs, unit, err := parseNutrition(p, fields)
+		if err != nil {
+			h.conversations.Expect(c.Sender().ID, h.productStep(draft))
+			return c.Send(err.Error())
+		}
+
+		h.mu.Lock()
+		delete(h.drafts, c.Sender().ID)
+		h.mu.Unlock()
+
+		product, created, err := h.db.GetOrCreateProduct(ctx, draft.userID, draft.name, ccal, fats, proteins, carbs, unit)
+		if err != nil {
+			log.Printf("Error inserting product: %v", err)
+			return c.Send(p.Sprintf("❌ Error creating product: %v", err))
+		}
+
+		if _, err := h.insertItem(ctx, draft.userID, draft.path, draft.name, &product.UUID); err != nil {
+			log.Printf("Error inserting common item: %v", err)
+			return c.Send(p.Sprintf("❌ Error adding item: %v", err))
+		}
+
+		// The catalog keeps one product per name, so tell the user the
+		// nutrition they sent was not used.
+		if !created && (product.Ccal != ccal || product.Fats != fats || product.Proteins != proteins ||
+			product.Carbs != carbs || product.Unit != unit) {
+			notice := p.Sprintf("ℹ️ Your catalog already has %s with %d kcal per %s, the item uses it. "+
+				"Change its nutrition with /record.", product.Name, product.Ccal, models.FormatAmount(basePortion(product), p.T(product.Unit)))
+			if err := c.Send(notice); err != nil {
+				log.Printf("Error sending message: %v", err)
+			}
+		}
+
+		return h.showLocationsLevel(c, draft.path)
+	}
+}
+
+// insertItem adds a node named name under parentPath with a label derived
+// from the name that is unique among its siblings.
+func (h *MenuHandler) insertItem(ctx context.Context, userID int64, parentPath, name string, productUUID *uuid.UUID) (*models.UserCommonItem, error) {
+	siblings, err := h.db.GetUserCommonItemsAtLevel(ctx, userID, parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	taken := make(map[string]bool, len(siblings))
+	for _, sibling := range siblings {
+		taken[sibling.Path] = true
+	}
+
+	base := childPath(parentPath, pathLabel(name))
+	path := base
+	for i := 2; taken[path]; i++ {
+		path = fmt.Sprintf("%s_%d", base, i)
+	}
+
+	return h.db.InsertUserCommonItem(ctx, userID, path, name, productUUID)
+}
+
+func childPath(parentPath, label string) string {
+	if parentPath == "" {
+		return label
+	}
+	return parentPath + "." + label
+}
+
+// pathLabel turns a display name into an ltree label, which may only
+// contain ASCII letters, digits and underscores.
+func pathLabel(name string) string {
+	var label strings.Builder
+	underscore := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			label.WriteRune(r)
+			underscore = false
+		} else if !underscore && label.Len() > 0 {
+			label.WriteByte('_')
+			underscore = true
+		}
+	}
+
+	result := strings.TrimSuffix(label.String(), "_")
+	if result == "" {
+		return "item"
+	}
+	if len(result) > 32 {
+		result = strings.TrimSuffix(result[:32], "_")
+	}
+	return result
+}
+
+// parseNutrition parses "<ccal> [proteins] [fats] [carbs] [unit]" where the
+// unit may be given as "g", "100g", "ml" or "pcs".
+func parseNutrition(p *i18n.Printer, fields []string) (ccal, fats, proteins, carbs int64, unit string, err error) {
+	unit = models.UnitPiece
+
+	last := fields[len(fields)-1]
+	if _, parsedUnit, parseErr := models.ParseAmount("1" + last); parseErr == nil && parsedUnit != "" {
+		unit = parsedUnit
+		fields = fields[:len(fields)-1]
+	}
+
+	values := make([]int64, 4)
+	for i, field := range fields {
+		if i >= len(values) {
+			return 0, 0, 0, 0, "", errors.New(p.T("Too many values. Use: <ccal> [proteins] [fats] [carbs] [g|ml|pcs]"))
+		}
+		values[i], err = strconv.ParseInt(field, 10, 64)
+		if err != nil || values[i] < 0 {
+			return 0, 0, 0, 0, "", errors.New(p.Sprintf("Invalid number %q", field))
+		}
+	}
+
+	if values[0] <= 0 {
+		return 0, 0, 0, 0, "", errors.New(p.T("Calories must be a positive number"))
+	}
+
+	return values[0], values[2], values[1], values[3], unit, nil
+}
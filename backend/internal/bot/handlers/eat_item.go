@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/i18n"
+	"backend/internal/models"
+	"backend/internal/storage"
+	"backend/internal/timeout"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// portionMultipliers are the quick amounts offered for a common item, in
+// portions of one piece or 100 g/ml.
+var portionMultipliers = []float64{0.5, 1, 2}
+
+// HandleEatCallback shows the amount picker for a food item of the tree.
+func (h *MenuHandler) HandleEatCallback(c tele.Context, data string) error {
+	p := i18n.From(c)
+	item, product, err := h.loadFoodItem(c, data)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	menu := &tele.ReplyMarkup{}
+	var quick []tele.Btn
+	for _, multiplier := range portionMultipliers {
+		value := strconv.FormatFloat(multiplier, 'f', -1, 64)
+		quick = append(quick, menu.Data("×"+value, "eatx:"+item.UUID.String()+"|"+value))
+	}
+	menu.Inline(
+		menu.Row(quick...),
+		menu.Row(menu.Data(p.T("✍️ Custom amount"), "eatc:"+item.UUID.String())),
+		menu.Row(menu.Data(p.T("⬅️ Back"), "nav:"+h.folderID(timeout.From(c), item.UserID, parentPath(item.Path)))),
+	)
+
+	portion := basePortion(product)
+	title := p.Sprintf("🍽️ %s\n×1 = %s · %.0f kcal\n\nHow much did you eat?",
+		item.Name, models.FormatAmount(portion, p.T(product.Unit)), product.Nutrition(portion).Kcal)
+
+	if err := c.Edit(title, menu); err != nil {
+		log.Printf("Error editing message: %v", err)
+		return c.Send(title, menu)
+	}
+
+	return c.Respond()
+}
+
+// HandleEatAmountCallback records a quick multiple of the item's portion.
+func (h *MenuHandler) HandleEatAmountCallback(c tele.Context, data string) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	itemID, value, found := strings.Cut(data, "|")
+	multiplier, err := strconv.ParseFloat(value, 64)
+	if !found || err != nil || multiplier <= 0 {
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Invalid amount")})
+	}
+
+	item, product, err := h.loadFoodItem(c, itemID)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	message, err := h.recordItem(ctx, p, item, product, basePortion(product)*multiplier, time.Time{})
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Error creating record")})
+	}
+
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data(p.T("⬅️ Back"), "nav:"+h.folderID(ctx, item.UserID, parentPath(item.Path)))))
+
+	if err := c.Edit(message, menu); err != nil {
+		log.Printf("Error editing message: %v", err)
+		return c.Send(message, menu)
+	}
+
+	return c.Respond(&tele.CallbackResponse{Text: p.T("Recorded")})
+}
+
+// HandleEatCustomCallback asks for an arbitrary amount of the item.
+func (h *MenuHandler) HandleEatCustomCallback(c tele.Context, data string) error {
+	p := i18n.From(c)
+	item, product, err := h.loadFoodItem(c, data)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	var step func(c tele.Context) error
+	step = func(c tele.Context) error {
+		ctx := timeout.From(c)
+		prefs := h.preferences(ctx, item.UserID)
+		when, fields, err := models.ParseWhen(strings.Fields(c.Text()), time.Now().In(prefs.Location()), prefs.Noon)
+		if err != nil {
+			h.conversations.Expect(c.Sender().ID, step)
+			return c.Send(p.T(err.Error()) + p.T(" (/cancel to stop)"))
+		}
+
+		amount, unit, err := models.ParseAmount(strings.Join(fields, ""))
+		if err != nil {
+			h.conversations.Expect(c.Sender().ID, step)
+			return c.Send(p.Sprintf("Invalid amount, send e.g. 150%s (/cancel to stop)", p.T(product.Unit)))
+		}
+		if unit != "" && unit != product.Unit {
+			h.conversations.Expect(c.Sender().ID, step)
+			return c.Send(p.Sprintf("%s is measured in %s, not %s", item.Name, p.T(product.Unit), p.T(unit)) + p.T(" (/cancel to stop)"))
+		}
+
+		message, err := h.recordItem(ctx, p, item, product, amount, when)
+		if err != nil {
+			return c.Send(p.Sprintf("❌ Error creating record: %v", err))
+		}
+
+		return c.Send(message)
+	}
+	h.conversations.Expect(c.Sender().ID, step)
+
+	if err := c.Respond(); err != nil {
+		log.Printf("Error answering callback: %v", err)
+	}
+	return c.Send(p.Sprintf("How much %s did you eat? Send an amount in %s, e.g. 150%s or 150%s -2h for earlier (/cancel to stop)",
+		item.Name, p.T(product.Unit), p.T(product.Unit), p.T(product.Unit)))
+}
+
+// loadFoodItem returns the sender's common item with the given UUID and its
+// product. Errors are suitable for showing to the user.
+func (h *MenuHandler) loadFoodItem(c tele.Context, data string) (*models.UserCommonItem, *models.ProductDetails, error) {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	item, err := h.loadItem(c, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if item.ProductUUID == nil {
+		return nil, nil, errors.New(p.T("Item not found"))
+	}
+
+	product, err := h.db.GetProductByUUID(ctx, *item.ProductUUID)
+	if err != nil {
+		log.Printf("Error getting product %s: %v", *item.ProductUUID, err)
+		return nil, nil, errors.New(p.T("Product not found"))
+	}
+
+	return item, product, nil
+}
+
+// recordItem records amount of the item eaten at when, or now for a zero
+// time, and returns the confirmation message.
+func (h *MenuHandler) recordItem(ctx context.Context, p *i18n.Printer, item *models.UserCommonItem, product *models.ProductDetails, amount float64, when time.Time) (string, error) {
+	record, err := storage.InsertRecord(ctx, h.db, item.UserID, product.UUID, amount, "", when)
+	if err != nil {
+		log.Printf("Error inserting record: %v", err)
+		return "", err
+	}
+
+	message := p.Sprintf("✅ Recorded: %s (%s)\n📊 Calories: %.0f",
+		item.Name, models.FormatAmount(record.Amount, p.T(record.Unit)), record.Nutrition().Kcal)
+	if !when.IsZero() {
+		loc := h.preferences(ctx, item.UserID).Location()
+		message += fmt.Sprintf("\n🕒 %s", record.CreatedAt.In(loc).Format("02-01 15:04"))
+	}
+	return message, nil
+}
+
+func (h *MenuHandler) preferences(ctx context.Context, userID int64) *models.UserPreferences {
+	return storage.UserPreferences(ctx, h.db, userID)
+}
+
+// basePortion is the amount a ×1 multiplier stands for.
+func basePortion(product *models.ProductDetails) float64 {
+	if product.Unit == models.UnitPiece {
+		return 1
+	}
+	return 100
+}
+
+func parentPath(path string) string {
+	if i := strings.LastIndex(path, "."); i != -1 {
+		return path[:i]
+	}
+	return ""
+}
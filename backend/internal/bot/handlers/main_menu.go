@@ -4,35 +4,74 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"sync"
 
-	"backend/internal/database"
+	"backend/internal/bot/conversation"
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/storage"
+	"backend/internal/timeout"
 
+	"github.com/google/uuid"
 	tele "gopkg.in/telebot.v3"
 )
 
+// Store is the data the menu works with; storage.Store implements it.
+type Store interface {
+	storage.Products
+	storage.Records
+	storage.Preferences
+	storage.CommonItems
+}
+
 type MenuHandler struct {
-	db           *database.DB
-	BtnLocations tele.Btn
+	db            Store
+	conversations *conversation.Conversations
+	BtnLocations  tele.Btn
+
+	mu     sync.Mutex
+	drafts map[int64]*itemDraft
+	moves  map[int64]uuid.UUID
 }
 
-func NewMenuHandler(db *database.DB) *MenuHandler {
+func NewMenuHandler(db Store, conversations *conversation.Conversations) *MenuHandler {
 	menu := &tele.ReplyMarkup{}
-	return &MenuHandler{
-		db:           db,
-		BtnLocations: menu.Data("📍 Locations", "menu_locations"),
+	h := &MenuHandler{
+		db:            db,
+		conversations: conversations,
+		BtnLocations:  menu.Data("📍 Locations", "menu_locations"),
+		drafts:        make(map[int64]*itemDraft),
+		moves:         make(map[int64]uuid.UUID),
 	}
+	conversations.OnCancel(h.forget)
+	return h
+}
+
+// forget drops the user's pending draft and move, so that their buttons do
+// nothing after /cancel. It reports whether there was either.
+func (h *MenuHandler) forget(userID int64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	
+	_, drafted := h.drafts[userID]
+	_, moving := h.moves[userID]
+	delete(h.drafts, userID)
+	delete(h.moves, userID)
+	return drafted || moving
 }
 
 func (h *MenuHandler) HandleMenu(c tele.Context) error {
+	p := i18n.From(c)
 	menu := &tele.ReplyMarkup{}
 	menu.Inline(
-		menu.Row(h.BtnLocations),
+		menu.Row(menu.Data(p.T(h.BtnLocations.Text), h.BtnLocations.Unique)),
 	)
 	
-	return c.Send("Main Menu:", menu)
+	return c.Send(p.T("Main Menu:"), menu)
 }
 
 func (h *MenuHandler) HandleCallback(c tele.Context) error {
+	p := i18n.From(c)
 	data := c.Callback().Data
 	
 	if len(data) > 0 && data[0] < 32 {
@@ -48,11 +87,59 @@ func (h *MenuHandler) HandleCallback(c tele.Context) error {
 	
 	if strings.HasPrefix(data, "add:") {
 		log.Printf("Matched add: prefix")
-		return c.Respond(&tele.CallbackResponse{Text: "Add item feature - coming soon!"})
+		return h.HandleAddCallback(c, strings.TrimPrefix(data, "add:"))
+	}
+	
+	if strings.HasPrefix(data, "addfolder:") {
+		return h.HandleAddFolderCallback(c, strings.TrimPrefix(data, "addfolder:"))
+	}
+	
+	if strings.HasPrefix(data, "addfood:") {
+		return h.HandleAddFoodCallback(c, strings.TrimPrefix(data, "addfood:"))
+	}
+	
+	if strings.HasPrefix(data, "addprod:") {
+		return h.HandleAddProductCallback(c, strings.TrimPrefix(data, "addprod:"))
+	}
+	
+	if strings.HasPrefix(data, "eat:") {
+		return h.HandleEatCallback(c, strings.TrimPrefix(data, "eat:"))
+	}
+	
+	if strings.HasPrefix(data, "eatx:") {
+		return h.HandleEatAmountCallback(c, strings.TrimPrefix(data, "eatx:"))
+	}
+	
+	if strings.HasPrefix(data, "eatc:") {
+		return h.HandleEatCustomCallback(c, strings.TrimPrefix(data, "eatc:"))
+	}
+	
+	if strings.HasPrefix(data, "manage:") {
+		return h.HandleManageCallback(c, strings.TrimPrefix(data, "manage:"))
+	}
+	
+	if strings.HasPrefix(data, "ren:") {
+		return h.HandleRenameCallback(c, strings.TrimPrefix(data, "ren:"))
+	}
+	
+	if strings.HasPrefix(data, "mv:") {
+		return h.HandleMoveCallback(c, strings.TrimPrefix(data, "mv:"))
+	}
+	
+	if strings.HasPrefix(data, "mvto:") {
+		return h.HandleMoveToCallback(c, strings.TrimPrefix(data, "mvto:"))
+	}
+	
+	if strings.HasPrefix(data, "del:") {
+		return h.HandleDeleteCallback(c, strings.TrimPrefix(data, "del:"))
+	}
+	
+	if strings.HasPrefix(data, "delok:") {
+		return h.HandleDeleteConfirmCallback(c, strings.TrimPrefix(data, "delok:"))
 	}
 	
 	log.Printf("Unknown callback action: '%s'", data)
-	return c.Respond(&tele.CallbackResponse{Text: "Unknown action"})
+	return c.Respond(&tele.CallbackResponse{Text: p.T("Unknown action")})
 }
 
 func (h *MenuHandler) HandleLocationsCallback(c tele.Context) error {
@@ -60,6 +147,7 @@ func (h *MenuHandler) HandleLocationsCallback(c tele.Context) error {
 }
 
 func (h *MenuHandler) HandleNavigationCallback(c tele.Context) error {
+	p := i18n.From(c)
 	data := c.Callback().Data
 	
 	if len(data) > 0 && data[0] < 32 {
@@ -67,24 +155,38 @@ func (h *MenuHandler) HandleNavigationCallback(c tele.Context) error {
 	}
 	
 	if !strings.HasPrefix(data, "nav:") {
-		return c.Respond(&tele.CallbackResponse{Text: "Invalid action"})
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Invalid action")})
 	}
 	
-	path := strings.TrimPrefix(data, "nav:")
+	path, err := h.folderPath(c, strings.TrimPrefix(data, "nav:"))
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
 	
 	return h.showLocationsLevel(c, path)
 }
 
 func (h *MenuHandler) showLocationsLevel(c tele.Context, parentPath string) error {
-	login := c.Sender().Username
-	if login == "" {
-		login = fmt.Sprintf("user_%d", c.Sender().ID)
-	}
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	userID := identity.From(c)
 	
-	items, err := h.db.GetUserCommonItemsAtLevel(login, parentPath)
+	items, err := h.db.GetUserCommonItemsAtLevel(ctx, userID, parentPath)
 	if err != nil {
 		log.Printf("Error getting items at level: %v", err)
-		return c.Respond(&tele.CallbackResponse{Text: "Error loading items"})
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Error loading items")})
+	}
+	
+	title := p.T("📍 Locations")
+	folderID := ""
+	if parentPath != "" {
+		parent, err := h.db.GetUserCommonItemByPath(ctx, userID, parentPath)
+		if err != nil {
+			log.Printf("Error getting common item at %s: %v", parentPath, err)
+			return c.Respond(&tele.CallbackResponse{Text: p.T("Item not found")})
+		}
+		title = fmt.Sprintf("📂 %s", parent.Name)
+		folderID = parent.UUID.String()
 	}
 	
 	menu := &tele.ReplyMarkup{}
@@ -92,17 +194,24 @@ func (h *MenuHandler) showLocationsLevel(c tele.Context, parentPath string) erro
 	
 	for _, item := range items {
 		icon := "📁"
+		action := "nav:" + item.UUID.String()
 		if item.ProductUUID != nil {
 			icon = "🍽️"
+			action = "eat:" + item.UUID.String()
 		}
 		
 		btnText := fmt.Sprintf("%s %s", icon, item.Name)
-		btn := menu.Data(btnText, "nav:"+item.Path)
+		btn := menu.Data(btnText, action)
 		rows = append(rows, menu.Row(btn))
 	}
 	
-	btnAdd := menu.Data("➕ Add item", "add:"+parentPath)
-	rows = append(rows, menu.Row(btnAdd))
+	btnAdd := menu.Data(p.T("➕ Add item"), "add:"+folderID)
+	if len(items) > 0 {
+		btnManage := menu.Data(p.T("⚙️ Manage"), "manage:"+folderID)
+		rows = append(rows, menu.Row(btnAdd, btnManage))
+	} else {
+		rows = append(rows, menu.Row(btnAdd))
+	}
 	
 	if parentPath != "" {
 		parts := strings.Split(parentPath, ".")
@@ -110,19 +219,18 @@ func (h *MenuHandler) showLocationsLevel(c tele.Context, parentPath string) erro
 		if len(parts) > 1 {
 			parentPathStr = strings.Join(parts[:len(parts)-1], ".")
 		}
-		btnBack := menu.Data("⬅️ Back", "nav:"+parentPathStr)
+		btnBack := menu.Data(p.T("⬅️ Back"), "nav:"+h.folderID(ctx, userID, parentPathStr))
 		rows = append(rows, menu.Row(btnBack))
 	}
 	
 	menu.Inline(rows...)
 	
-	title := "📍 Locations"
-	if parentPath != "" {
-		title = fmt.Sprintf("📂 %s", parentPath)
+	if len(items) == 0 {
+		title += p.T("\n\n(Empty - click ➕ to add items)")
 	}
 	
-	if len(items) == 0 {
-		title += "\n\n(Empty - click ➕ to add items)"
+	if c.Callback() == nil {
+		return c.Send(title, menu)
 	}
 	
 	err = c.Edit(title, menu)
@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"backend/internal/bot/bottest"
+	"backend/internal/bot/conversation"
+	"backend/internal/models"
+	"backend/internal/storage/memory"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+var alice = &tele.User{ID: 42, Username: "alice", FirstName: "Alice", LanguageCode: "en"}
+
+// seedItems stores a folder "Fruits" with the food item "Apple" and an empty
+// folder "Vegetables", and returns a replacer for the {fruits}, {apple} and
+// {vegetables} placeholders with their UUIDs.
+func seedItems(t *testing.T, store *memory.Store) *strings.Replacer {
+	t.Helper()
+
+	product, _, err := store.GetOrCreateProduct(context.Background(), alice.ID, "Apple", 52, 0, 0, 14, models.UnitPiece)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fruits, err := store.InsertUserCommonItem(context.Background(), alice.ID, "fruits", "Fruits", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apple, err := store.InsertUserCommonItem(context.Background(), alice.ID, "fruits.apple", "Apple", &product.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vegetables, err := store.InsertUserCommonItem(context.Background(), alice.ID, "vegetables", "Vegetables", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.NewReplacer("{fruits}", fruits.UUID.String(), "{apple}", apple.UUID.String(),
+		"{vegetables}", vegetables.UUID.String())
+}
+
+func TestHandleCallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		edited   string
+		buttons  [][]string
+		response string
+	}{
+		{
+			name:   "locations",
+			data:   "\fnav:",
+			edited: "📍 Locations",
+			buttons: [][]string{
+				{"\fnav:{fruits}"},
+				{"\fnav:{vegetables}"},
+				{"\fadd:", "\fmanage:"},
+			},
+		},
+		{
+			name:   "folder",
+			data:   "\fnav:{fruits}",
+			edited: "📂 Fruits",
+			buttons: [][]string{
+				{"\feat:{apple}"},
+				{"\fadd:{fruits}", "\fmanage:{fruits}"},
+				{"\fnav:"},
+			},
+		},
+		{
+			name:   "empty folder",
+			data:   "\fnav:{vegetables}",
+			edited: "📂 Vegetables\n\n(Empty - click ➕ to add items)",
+			buttons: [][]string{
+				{"\fadd:{vegetables}"},
+				{"\fnav:"},
+			},
+		},
+		{
+			name:     "navigate to food item",
+			data:     "\fnav:{apple}",
+			response: "Invalid item",
+		},
+		{
+			name:     "navigate to missing folder",
+			data:     "\fnav:" + uuid.New().String(),
+			response: "Item not found",
+		},
+		{
+			name:   "add",
+			data:   "\fadd:{fruits}",
+			edited: "What do you want to add?",
+			buttons: [][]string{
+				{"\faddfolder:{fruits}", "\faddfood:{fruits}"},
+				{"\fnav:{fruits}"},
+			},
+		},
+		{
+			name:   "eat",
+			data:   "\feat:{apple}",
+			edited: "🍽️ Apple\n×1 = 1 pcs · 52 kcal\n\nHow much did you eat?",
+			buttons: [][]string{
+				{"\featx:{apple}|0.5", "\featx:{apple}|1", "\featx:{apple}|2"},
+				{"\featc:{apple}"},
+				{"\fnav:{fruits}"},
+			},
+		},
+		{
+			name:     "eat amount",
+			data:     "\featx:{apple}|2",
+			edited:   "✅ Recorded: Apple (2 pcs)\n📊 Calories: 104",
+			buttons:  [][]string{{"\fnav:{fruits}"}},
+			response: "Recorded",
+		},
+		{
+			name:     "eat invalid amount",
+			data:     "\featx:{apple}|lots",
+			response: "Invalid amount",
+		},
+		{
+			name:     "eat folder",
+			data:     "\feat:{fruits}",
+			response: "Item not found",
+		},
+		{
+			name:     "eat missing item",
+			data:     "\feat:" + uuid.New().String(),
+			response: "Item not found",
+		},
+		{
+			name:     "rename invalid item",
+			data:     "\fren:nope",
+			response: "Invalid item",
+		},
+		{
+			name:     "unknown action",
+			data:     "\fbogus:1",
+			response: "Unknown action",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.New()
+			h := NewMenuHandler(store, conversation.New())
+			ids := seedItems(t, store)
+
+			c := bottest.NewCallback(alice, ids.Replace(tt.data))
+			if err := h.HandleCallback(c); err != nil {
+				t.Fatalf("HandleCallback failed: %v", err)
+			}
+
+			if len(c.Sent) > 0 {
+				t.Errorf("sent %q instead of editing", c.LastSent().Text)
+			}
+
+			edited := c.LastEdited()
+			if edited.Text != tt.edited {
+				t.Errorf("edited to %q, want %q", edited.Text, tt.edited)
+			}
+			if tt.buttons != nil {
+				want := ids.Replace(fmt.Sprintf("%q", tt.buttons))
+				if got := fmt.Sprintf("%q", edited.Buttons()); got != want {
+					t.Errorf("buttons %s, want %s", got, want)
+				}
+			}
+
+			if len(c.Responses) != 1 {
+				t.Fatalf("answered the callback %d times, want once", len(c.Responses))
+			}
+			if got := c.LastResponse().Text; got != tt.response {
+				t.Errorf("response %q, want %q", got, tt.response)
+			}
+		})
+	}
+}
+
+func TestCallbackDataFitsTelegramLimit(t *testing.T) {
+	store := memory.New()
+	h := NewMenuHandler(store, conversation.New())
+
+	long := strings.Repeat("a", 32)
+	outer, err := store.InsertUserCommonItem(context.Background(), alice.ID, long, long, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inner, err := store.InsertUserCommonItem(context.Background(), alice.ID, long+"."+long, long, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.InsertUserCommonItem(context.Background(), alice.ID, long+"."+long+"."+long, long, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range []string{"\fnav:" + inner.UUID.String(), "\fadd:" + inner.UUID.String(), "\fmanage:" + outer.UUID.String()} {
+		c := bottest.NewCallback(alice, data)
+		if err := h.HandleCallback(c); err != nil {
+			t.Fatalf("HandleCallback(%q) failed: %v", data, err)
+		}
+
+		for _, row := range c.LastEdited().Buttons() {
+			for _, button := range row {
+				if len(button) > 64 {
+					t.Errorf("%q: button data %q is %d bytes, Telegram allows 64", data, button, len(button))
+				}
+			}
+		}
+	}
+}
+
+func TestAddForeignProduct(t *testing.T) {
+	store := memory.New()
+	h := NewMenuHandler(store, conversation.New())
+
+	foreign, _, err := store.GetOrCreateProduct(context.Background(), 7, "Secret Cake", 400, 5, 20, 50, models.UnitPiece)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.drafts[alice.ID] = &itemDraft{userID: alice.ID, name: "Cake"}
+
+	c := bottest.NewCallback(alice, "\faddprod:"+foreign.UUID.String())
+	if err := h.HandleCallback(c); err != nil {
+		t.Fatalf("HandleCallback failed: %v", err)
+	}
+
+	if got := c.LastResponse().Text; got != "Product not found" {
+		t.Errorf("response %q, want %q", got, "Product not found")
+	}
+	items, err := store.GetUserCommonItemsByUser(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 0 {
+		t.Errorf("added %d items with a foreign product", len(items))
+	}
+}
+
+func TestAddItemWithExistingProduct(t *testing.T) {
+	store := memory.New()
+	h := NewMenuHandler(store, conversation.New())
+
+	apple, _, err := store.GetOrCreateProduct(context.Background(), alice.ID, "Apple", 52, 0, 0, 14, models.UnitPiece)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := bottest.NewMessage(alice, "90 1 0 20")
+	if err := h.productStep(&itemDraft{userID: alice.ID, name: "Apple"})(c); err != nil {
+		t.Fatalf("productStep failed: %v", err)
+	}
+
+	want := "ℹ️ Your catalog already has Apple with 52 kcal per 1 pcs, the item uses it. Change its nutrition with /record."
+	if len(c.Sent) == 0 || c.Sent[0].Text != want {
+		t.Errorf("sent %q, want the notice %q first", c.Sent, want)
+	}
+
+	items, err := store.GetUserCommonItemsByUser(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].ProductUUID == nil || *items[0].ProductUUID != apple.UUID {
+		t.Errorf("items %+v, want one item with the existing product", items)
+	}
+}
+
+func TestEatCustomAmount(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"amount", "3", "✅ Recorded: Apple (3 pcs)\n📊 Calories: 156"},
+		{"other unit", "100g", "Apple is measured in pcs, not g (/cancel to stop)"},
+		{"invalid amount", "lots", "Invalid amount, send e.g. 150pcs (/cancel to stop)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := memory.New()
+			conversations := conversation.New()
+			h := NewMenuHandler(store, conversations)
+			ids := seedItems(t, store)
+
+			if err := h.HandleCallback(bottest.NewCallback(alice, ids.Replace("\featc:{apple}"))); err != nil {
+				t.Fatalf("HandleCallback failed: %v", err)
+			}
+
+			c := bottest.NewMessage(alice, tt.text)
+			if err := conversations.HandleText(c); err != nil {
+				t.Fatalf("HandleText failed: %v", err)
+			}
+			if got := c.LastSent().Text; got != tt.want {
+				t.Errorf("sent %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestButtonsAfterCancel(t *testing.T) {
+	store := memory.New()
+	conversations := conversation.New()
+	h := NewMenuHandler(store, conversations)
+	ids := seedItems(t, store)
+	apple, err := store.GetProductByName(context.Background(), alice.ID, "Apple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cancel := func() {
+		t.Helper()
+		c := bottest.NewMessage(alice, "/cancel")
+		if err := conversations.HandleCancel(c); err != nil {
+			t.Fatalf("HandleCancel failed: %v", err)
+		}
+		if got := c.LastSent().Text; got != "❌ Cancelled" {
+			t.Errorf("reply to /cancel %q, want %q", got, "❌ Cancelled")
+		}
+	}
+	tap := func(data string) string {
+		t.Helper()
+		c := bottest.NewCallback(alice, ids.Replace(data))
+		if err := h.HandleCallback(c); err != nil {
+			t.Fatalf("%q failed: %v", data, err)
+		}
+		return c.LastResponse().Text
+	}
+
+	// The product picks offered for a new item.
+	tap("\faddfood:{fruits}")
+	if err := conversations.HandleText(bottest.NewMessage(alice, "Green Apple")); err != nil {
+		t.Fatalf("sending the name failed: %v", err)
+	}
+	cancel()
+	if got, want := tap("\faddprod:"+apple.UUID.String()), "Nothing to add, start again with ➕"; got != want {
+		t.Errorf("response to a pick after /cancel %q, want %q", got, want)
+	}
+
+	// The targets offered for a move, which waits for no message.
+	tap("\fmv:{apple}")
+	cancel()
+	if got, want := tap("\fmvto:{vegetables}"), "Nothing to move"; got != want {
+		t.Errorf("response to a target after /cancel %q, want %q", got, want)
+	}
+
+	items, err := store.GetUserCommonItemsByUser(context.Background(), alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, item := range items {
+		paths = append(paths, item.Path)
+	}
+	if want := "fruits fruits.apple vegetables"; strings.Join(paths, " ") != want {
+		t.Errorf("item paths %q, want %q", paths, want)
+	}
+}
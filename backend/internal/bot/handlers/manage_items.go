@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/models"
+	"backend/internal/timeout"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+// HandleManageCallback lists the items of the folder with the given ID, or
+// of the top level for an empty one, with rename, move and delete buttons.
+func (h *MenuHandler) HandleManageCallback(c tele.Context, folderID string) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	userID := identity.From(c)
+	parentPath, err := h.folderPath(c, folderID)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	items, err := h.db.GetUserCommonItemsAtLevel(ctx, userID, parentPath)
+	if err != nil {
+		log.Printf("Error getting items at level: %v", err)
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Error loading items")})
+	}
+
+	menu := &tele.ReplyMarkup{}
+	var rows []tele.Row
+	for _, item := range items {
+		id := item.UUID.String()
+		rows = append(rows, menu.Row(
+			menu.Data("✏️ "+item.Name, "ren:"+id),
+			menu.Data(p.T("📦 Move"), "mv:"+id),
+			menu.Data("🗑", "del:"+id),
+		))
+	}
+	rows = append(rows, menu.Row(menu.Data(p.T("✅ Done"), "nav:"+folderID)))
+	menu.Inline(rows...)
+
+	if err := c.Edit(p.T("⚙️ Manage items\n\n✏️ rename · 📦 move · 🗑 delete"), menu); err != nil {
+		log.Printf("Error editing message: %v", err)
+	}
+
+	return c.Respond()
+}
+
+func (h *MenuHandler) HandleRenameCallback(c tele.Context, data string) error {
+	p := i18n.From(c)
+	item, err := h.loadItem(c, data)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	h.conversations.Expect(c.Sender().ID, func(c tele.Context) error {
+		ctx := timeout.From(c)
+		name := strings.TrimSpace(c.Text())
+		if name == "" {
+			return c.Send(p.T("The name cannot be empty"))
+		}
+
+		if _, err := h.db.RenameUserCommonItem(ctx, item.UserID, item.UUID, name); err != nil {
+			log.Printf("Error renaming common item: %v", err)
+			return c.Send(p.Sprintf("❌ Error renaming item: %v", err))
+		}
+
+		return h.showLocationsLevel(c, parentPath(item.Path))
+	})
+
+	if err := c.Respond(); err != nil {
+		log.Printf("Error answering callback: %v", err)
+	}
+	return c.Send(p.Sprintf("✏️ Send a new name for \"%s\" (/cancel to stop)", item.Name))
+}
+
+// HandleMoveCallback offers the folders an item can be moved to.
+func (h *MenuHandler) HandleMoveCallback(c tele.Context, data string) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	item, err := h.loadItem(c, data)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	all, err := h.db.GetUserCommonItemsByUser(ctx, item.UserID)
+	if err != nil {
+		log.Printf("Error getting common items: %v", err)
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Error loading items")})
+	}
+
+	currentParent := parentPath(item.Path)
+
+	menu := &tele.ReplyMarkup{}
+	var rows []tele.Row
+	if currentParent != "" {
+		rows = append(rows, menu.Row(menu.Data(p.T("📍 Top level"), "mvto:")))
+	}
+	for _, folder := range all {
+		if folder.ProductUUID != nil || folder.Path == currentParent ||
+			folder.Path == item.Path || strings.HasPrefix(folder.Path, item.Path+".") {
+			continue
+		}
+
+		indent := strings.Repeat("  ", strings.Count(folder.Path, "."))
+		rows = append(rows, menu.Row(menu.Data(indent+"📁 "+folder.Name, "mvto:"+folder.UUID.String())))
+	}
+	rows = append(rows, menu.Row(menu.Data(p.T("⬅️ Back"), "manage:"+h.folderID(ctx, item.UserID, currentParent))))
+	menu.Inline(rows...)
+
+	h.mu.Lock()
+	h.moves[c.Sender().ID] = item.UUID
+	h.mu.Unlock()
+
+	if err := c.Edit(p.Sprintf("📦 Move \"%s\" to:", item.Name), menu); err != nil {
+		log.Printf("Error editing message: %v", err)
+	}
+
+	return c.Respond()
+}
+
+// HandleMoveToCallback moves the item picked in HandleMoveCallback under
+// the chosen folder, or to the top level for an empty target.
+func (h *MenuHandler) HandleMoveToCallback(c tele.Context, data string) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	h.mu.Lock()
+	itemUUID, ok := h.moves[c.Sender().ID]
+	delete(h.moves, c.Sender().ID)
+	h.mu.Unlock()
+
+	if !ok {
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Nothing to move")})
+	}
+
+	item, err := h.loadItem(c, itemUUID.String())
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	targetPath := ""
+	if data != "" {
+		target, err := h.loadItem(c, data)
+		if err != nil {
+			return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+		}
+		if target.ProductUUID != nil {
+			return c.Respond(&tele.CallbackResponse{Text: p.T("Items can only be moved into folders")})
+		}
+		targetPath = target.Path
+	}
+
+	if _, err := h.db.MoveUserCommonItemSubtree(ctx, item.UserID, item.UUID, targetPath); err != nil {
+		log.Printf("Error moving common item: %v", err)
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Error moving item")})
+	}
+
+	return h.showLocationsLevel(c, targetPath)
+}
+
+func (h *MenuHandler) HandleDeleteCallback(c tele.Context, data string) error {
+	p := i18n.From(c)
+	item, err := h.loadItem(c, data)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	text := p.Sprintf("🗑 Delete \"%s\"?", item.Name)
+	if item.ProductUUID == nil {
+		text = p.Sprintf("🗑 Delete folder \"%s\" with everything inside?", item.Name)
+	}
+
+	menu := &tele.ReplyMarkup{}
+	menu.Inline(menu.Row(
+		menu.Data(p.T("🗑 Delete"), "delok:"+item.UUID.String()),
+		menu.Data(p.T("Cancel"), "manage:"+h.folderID(timeout.From(c), item.UserID, parentPath(item.Path))),
+	))
+
+	if err := c.Edit(text, menu); err != nil {
+		log.Printf("Error editing message: %v", err)
+	}
+
+	return c.Respond()
+}
+
+func (h *MenuHandler) HandleDeleteConfirmCallback(c tele.Context, data string) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	item, err := h.loadItem(c, data)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: err.Error()})
+	}
+
+	if _, err := h.db.DeleteUserCommonItemSubtree(ctx, item.UserID, item.UUID); err != nil {
+		log.Printf("Error deleting common item: %v", err)
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Error deleting item")})
+	}
+
+	return h.showLocationsLevel(c, parentPath(item.Path))
+}
+
+// loadItem returns the sender's common item with the given UUID. Errors are
+// suitable for showing to the user.
+func (h *MenuHandler) loadItem(c tele.Context, data string) (*models.UserCommonItem, error) {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	itemUUID, err := uuid.Parse(data)
+	if err != nil {
+		return nil, errors.New(p.T("Invalid item"))
+	}
+
+	userID := identity.From(c)
+
+	item, err := h.db.GetUserCommonItemByUUID(ctx, userID, itemUUID)
+	if err != nil {
+		log.Printf("Error getting common item %s: %v", itemUUID, err)
+		return nil, errors.New(p.T("Item not found"))
+	}
+
+	return item, nil
+}
+
+// folderPath returns the path of the sender's folder whose ID a button
+// carries, or the top level for an empty ID. Buttons carry IDs rather than
+// paths, which would not fit in Telegram's 64 bytes of callback data.
+func (h *MenuHandler) folderPath(c tele.Context, folderID string) (string, error) {
+	if folderID == "" {
+		return "", nil
+	}
+
+	item, err := h.loadItem(c, folderID)
+	if err != nil {
+		return "", err
+	}
+	if item.ProductUUID != nil {
+		return "", errors.New(i18n.From(c).T("Invalid item"))
+	}
+
+	return item.Path, nil
+}
+
+// folderID returns the ID a button carries for the folder at path, falling
+// back to the top level if it cannot be found.
+func (h *MenuHandler) folderID(ctx context.Context, userID int64, path string) string {
+	if path == "" {
+		return ""
+	}
+
+	folder, err := h.db.GetUserCommonItemByPath(ctx, userID, path)
+	if err != nil {
+		log.Printf("Error getting common item at %s: %v", path, err)
+		return ""
+	}
+
+	return folder.UUID.String()
+}
@@ -0,0 +1,757 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/bot/bottest"
+	"backend/internal/bot/conversation"
+	"backend/internal/models"
+	"backend/internal/storage/memory"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+var (
+	alice = &tele.User{ID: 42, Username: "alice", FirstName: "Alice", LanguageCode: "en"}
+	bob   = &tele.User{ID: 7, Username: "bob", FirstName: "Bob", LanguageCode: "en"}
+)
+
+func newTestHandler() (*BotHandler, *memory.Store) {
+	store := memory.New()
+	return NewBotHandler(store, conversation.New()), store
+}
+
+func addProduct(t *testing.T, store *memory.Store, name string, ccal, proteins, fats, carbs int64, unit string) *models.ProductDetails {
+	t.Helper()
+	product, _, err := store.GetOrCreateProduct(context.Background(), alice.ID, name, ccal, fats, proteins, carbs, unit)
+	if err != nil {
+		t.Fatalf("adding product %s: %v", name, err)
+	}
+	return product
+}
+
+func addRecord(t *testing.T, store *memory.Store, product *models.ProductDetails, amount float64, meal string, at time.Time) *models.Record {
+	t.Helper()
+	record, err := store.InsertRecordAt(context.Background(), product.UUID, amount, alice.ID, meal, at)
+	if err != nil {
+		t.Fatalf("adding record of %s: %v", product.Name, err)
+	}
+	return record
+}
+
+func TestCommands(t *testing.T) {
+	missing := uuid.New().String()
+
+	tests := []struct {
+		name    string
+		handler func(*BotHandler, tele.Context) error
+		text    string
+		setup   func(t *testing.T, store *memory.Store)
+		want    string
+	}{
+		{
+			name:    "start",
+			handler: (*BotHandler).HandleStart,
+			text:    "/start",
+			want:    "Welcome to C-Meter! 👋\n\nUse /help to see available commands.",
+		},
+		{
+			name:    "ping",
+			handler: (*BotHandler).HandlePing,
+			text:    "/ping",
+			want:    "✅ Database connected\n📦 Schema version: in-memory",
+		},
+		{
+			name:    "get without records",
+			handler: (*BotHandler).HandleGet,
+			text:    "/get",
+			want:    "No records found for the last day",
+		},
+		{
+			name:    "get without records for days",
+			handler: (*BotHandler).HandleGet,
+			text:    "/get 3",
+			want:    "No records found for the last 3 days",
+		},
+		{
+			name:    "get invalid days",
+			handler: (*BotHandler).HandleGet,
+			text:    "/get 0",
+			want:    "Usage: /get [days]\nDays must be between 1 and 31",
+		},
+		{
+			name:    "get too many days",
+			handler: (*BotHandler).HandleGet,
+			text:    "/get 99999999999",
+			want:    "Usage: /get [days]\nDays must be between 1 and 31",
+		},
+		{
+			name:    "stats too many days",
+			handler: (*BotHandler).HandleStats,
+			text:    "/stats 99999999999",
+			want:    "Usage: /stats [week|month|N]\nN is a number of days from 1 to 365\nExample: /stats month or /stats 14",
+		},
+		{
+			name:    "record usage",
+			handler: (*BotHandler).HandleRecord,
+			text:    "/record",
+			want:    recordUsage,
+		},
+		{
+			name:    "record unknown product",
+			handler: (*BotHandler).HandleRecord,
+			text:    "/record Kefir",
+			want: "🤷 \"Kefir\" is not in your catalog yet.\n" +
+				"Record it once with its nutrition: /record Kefir 100g <ccal> [proteins] [fats] [carbs]",
+		},
+		{
+			name:    "record unknown product with similar ones",
+			handler: (*BotHandler).HandleRecord,
+			text:    "/record Apple",
+			setup: func(t *testing.T, store *memory.Store) {
+				addProduct(t, store, "Green Apple", 52, 0, 0, 14, models.UnitPiece)
+			},
+			want: "🤷 \"Apple\" is not in your catalog yet.\n" +
+				"Record it once with its nutrition: /record Apple 100g <ccal> [proteins] [fats] [carbs]\n\n" +
+				"Similar products:\n• Green Apple",
+		},
+		{
+			name:    "record invalid calories",
+			handler: (*BotHandler).HandleRecord,
+			text:    "/record Kefir 250ml -5",
+			want:    "Calories must be a positive number",
+		},
+		{
+			name:    "record unknown meal",
+			handler: (*BotHandler).HandleRecord,
+			text:    "/record Kefir #brunch",
+			want:    "Unknown meal #brunch. Use #breakfast, #lunch, #dinner, #snack",
+		},
+		{
+			name:    "record in another unit",
+			handler: (*BotHandler).HandleRecord,
+			text:    "/record Apple 100g",
+			setup: func(t *testing.T, store *memory.Store) {
+				addProduct(t, store, "Apple", 52, 0, 0, 14, models.UnitPiece)
+			},
+			want: "Apple is measured in pcs, not g",
+		},
+		{
+			name:    "edit usage",
+			handler: (*BotHandler).HandleEdit,
+			text:    "/edit",
+			want:    "Usage: /edit <id> <amount|kcal>\nExample: /edit <id> 200g or /edit <id> 350kcal",
+		},
+		{
+			name:    "edit invalid id",
+			handler: (*BotHandler).HandleEdit,
+			text:    "/edit 123 200g",
+			want:    "Invalid record ID",
+		},
+		{
+			name:    "edit missing record",
+			handler: (*BotHandler).HandleEdit,
+			text:    "/edit " + missing + " 200g",
+			want:    "Record not found",
+		},
+		{
+			name:    "delete missing record",
+			handler: (*BotHandler).HandleDelete,
+			text:    "/delete " + missing,
+			want:    "Record not found",
+		},
+		{
+			name:    "set noon",
+			handler: (*BotHandler).HandleSetNoon,
+			text:    "/set_noon 03:00",
+			want:    "✅ Day flip time set to 03:00",
+		},
+		{
+			name:    "set noon invalid",
+			handler: (*BotHandler).HandleSetNoon,
+			text:    "/set_noon 25:00",
+			want:    "Invalid time format. Use HH:MM (e.g., 03:00)",
+		},
+		{
+			name:    "set lang",
+			handler: (*BotHandler).HandleSetLang,
+			text:    "/set_lang ru",
+			want:    "✅ Язык: ru",
+		},
+		{
+			name:    "set lang unsupported",
+			handler: (*BotHandler).HandleSetLang,
+			text:    "/set_lang de",
+			want:    "Unsupported language. Available: en, ru",
+		},
+		{
+			name:    "set timezone unknown",
+			handler: (*BotHandler).HandleSetTimezone,
+			text:    "/set_tz Mars/Olympus",
+			want:    "Unknown timezone. Use an IANA name such as Europe/Moscow or America/New_York",
+		},
+		{
+			name:    "set goal",
+			handler: (*BotHandler).HandleSetGoal,
+			text:    "/set_goal 2000 120 70 220",
+			want:    "✅ Daily goals set: 2000 kcal, proteins 120 g, fats 70 g, carbs 220 g",
+		},
+		{
+			name:    "set goal off",
+			handler: (*BotHandler).HandleSetGoal,
+			text:    "/set_goal off",
+			want:    "✅ Daily goals cleared",
+		},
+		{
+			name:    "set goal too many values",
+			handler: (*BotHandler).HandleSetGoal,
+			text:    "/set_goal 1 2 3 4 5",
+			want:    "Too many values. Usage: /set_goal <kcal> [proteins] [fats] [carbs]",
+		},
+		{
+			name:    "set goal invalid",
+			handler: (*BotHandler).HandleSetGoal,
+			text:    "/set_goal lots",
+			want:    "Goals must be non-negative numbers",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store := newTestHandler()
+			if tt.setup != nil {
+				tt.setup(t, store)
+			}
+
+			c := bottest.NewMessage(alice, tt.text)
+			if err := tt.handler(h, c); err != nil {
+				t.Fatalf("handler failed: %v", err)
+			}
+
+			if len(c.Sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(c.Sent))
+			}
+			if got := c.LastSent().Text; got != tt.want {
+				t.Errorf("reply:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordNewProduct(t *testing.T) {
+	h, store := newTestHandler()
+
+	c := bottest.NewMessage(alice, "/record Chicken Breast 200g 165 31 3 0 #dinner")
+	if err := h.HandleRecord(c); err != nil {
+		t.Fatalf("HandleRecord failed: %v", err)
+	}
+
+	product, err := store.GetProductByName(context.Background(), alice.ID, "Chicken Breast")
+	if err != nil {
+		t.Fatalf("product not stored: %v", err)
+	}
+	if product.Ccal != 165 || product.Proteins != 31 || product.Fats != 3 || product.Carbs != 0 || product.Unit != models.UnitGram {
+		t.Errorf("stored product %+v", product)
+	}
+
+	reply := c.LastSent()
+	want := "✅ Recorded: Chicken Breast (200 g)\n📊 Calories: 330\n🍽 Meal: dinner · 🕒 "
+	if !strings.HasPrefix(reply.Text, want) {
+		t.Fatalf("reply:\n%s\nwant prefix:\n%s", reply.Text, want)
+	}
+
+	id := reply.Text[strings.LastIndex(reply.Text, "ID: ")+len("ID: "):]
+	recordUUID, err := uuid.Parse(id)
+	if err != nil {
+		t.Fatalf("reply has no record ID: %v", err)
+	}
+	record, err := store.GetRecordByUUID(context.Background(), recordUUID)
+	if err != nil {
+		t.Fatalf("record not stored: %v", err)
+	}
+	if record.Amount != 200 || record.Meal != models.MealDinner || record.UserID != alice.ID {
+		t.Errorf("stored record %+v", record)
+	}
+
+	buttons := reply.Buttons()
+	wantButtons := []string{"\frecord_edit|" + id, "\frecord_delete|" + id}
+	if len(buttons) != 1 || strings.Join(buttons[0], " ") != strings.Join(wantButtons, " ") {
+		t.Errorf("buttons %q, want %q", buttons, wantButtons)
+	}
+}
+
+func TestRecordKnownProduct(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		// 23:30 is a snack unless the meal is given.
+		{"/record Apple @23:30", "✅ Recorded: Apple (1 pcs)\n📊 Calories: 52\n🍽 Meal: snack · 🕒 "},
+		{"/record apple 3pcs #snack", "✅ Recorded: Apple (3 pcs)\n📊 Calories: 156\n🍽 Meal: snack"},
+		{"/record Apple 2pcs #breakfast", "✅ Recorded: Apple (2 pcs)\n📊 Calories: 104\n🍽 Meal: breakfast"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			h, store := newTestHandler()
+			addProduct(t, store, "Apple", 52, 0, 0, 14, models.UnitPiece)
+
+			c := bottest.NewMessage(alice, tt.text)
+			if err := h.HandleRecord(c); err != nil {
+				t.Fatalf("HandleRecord failed: %v", err)
+			}
+
+			if got := c.LastSent().Text; !strings.HasPrefix(got, tt.want) {
+				t.Errorf("reply:\n%s\nwant prefix:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordNewNutritionKeepsHistory(t *testing.T) {
+	h, store := newTestHandler()
+	ctx := context.Background()
+	if err := store.UpsertUserTimezone(ctx, alice.ID, "UTC"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The chicken recorded yesterday keeps the nutrition it was recorded
+	// with after today's record brings new nutrition.
+	for _, text := range []string{
+		"/record Chicken Breast 200g 165 31 3 0 -24h",
+		"/record Chicken Breast 200g 200 31 3 0",
+	} {
+		if err := h.HandleRecord(bottest.NewMessage(alice, text)); err != nil {
+			t.Fatalf("%q failed: %v", text, err)
+		}
+	}
+
+	product, err := store.GetProductByName(ctx, alice.ID, "Chicken Breast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if product.Ccal != 200 {
+		t.Errorf("product has %d kcal per 100 g, want 200", product.Ccal)
+	}
+
+	now := time.Now()
+	totals, err := store.GetDailyTotals(ctx, alice.ID, now.Add(-48*time.Hour), now.Add(time.Hour), "UTC", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kcal []float64
+	for _, total := range totals {
+		kcal = append(kcal, total.Nutrition.Kcal)
+	}
+	if len(kcal) != 2 || kcal[0] != 330 || kcal[1] != 400 {
+		t.Errorf("daily kcal %v, want [330 400]", kcal)
+	}
+}
+
+func TestGetTable(t *testing.T) {
+	h, store := newTestHandler()
+	if err := store.UpsertUserTimezone(context.Background(), alice.ID, "UTC"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Start the day six hours ago so that all records fall into today.
+	now := time.Now().UTC()
+	flip := now.Add(-6 * time.Hour)
+	if err := store.UpsertUserNoon(context.Background(), alice.ID, time.Date(0, 1, 1, flip.Hour(), flip.Minute(), 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	oatmeal := addProduct(t, store, "Oatmeal", 350, 12, 6, 60, models.UnitGram)
+	chicken := addProduct(t, store, "Chicken Breast", 165, 31, 3, 0, models.UnitGram)
+	apple := addProduct(t, store, "Apple", 52, 0, 0, 14, models.UnitPiece)
+	at := []time.Time{now.Add(-3 * time.Hour), now.Add(-2 * time.Hour), now.Add(-time.Hour)}
+	addRecord(t, store, chicken, 200, models.MealLunch, at[1])
+	addRecord(t, store, oatmeal, 100, models.MealBreakfast, at[0])
+	addRecord(t, store, apple, 2, models.MealSnack, at[2])
+
+	c := bottest.NewMessage(alice, "/get")
+	if err := h.HandleGet(c); err != nil {
+		t.Fatalf("HandleGet failed: %v", err)
+	}
+
+	want := "<b>Today's records:</b>\n\n" +
+		"<pre> time │    name    │kcal│  P │  F │  C\n" +
+		"──────┼────────────┼────┼────┼────┼────\n" +
+		at[0].Format("15:04") + " │  Oatmeal   │ 350│  12│   6│  60\n" +
+		at[1].Format("15:04") + " │Chicken Brea│ 330│  62│   6│   0\n" +
+		at[2].Format("15:04") + " │   Apple    │ 104│   0│   0│  28\n" +
+		"──────┼────────────┼────┼────┼────┼────\n" +
+		"  Σ   │            │ 784│  74│  12│  88\n" +
+		"P 39% · F 14% · C 47%\n" +
+		"</pre>\n" +
+		"\n📋 <b>Total: 784 kcal · P 74 g · F 12 g · C 88 g</b>" +
+		"\n⚡ Energy: P 39% · F 14% · C 47%" +
+		"\n\n🍽 <b>Meals</b>\n<pre>" +
+		"breakfast   350 kcal  45% ████░░░░░░\n" +
+		"lunch       330 kcal  42% ████░░░░░░\n" +
+		"snack       104 kcal  13% █░░░░░░░░░\n" +
+		"</pre>"
+
+	reply := c.LastSent()
+	if reply.Text != want {
+		t.Errorf("reply:\n%s\nwant:\n%s", reply.Text, want)
+	}
+	if reply.ParseMode() != tele.ModeHTML {
+		t.Errorf("parse mode %q, want HTML", reply.ParseMode())
+	}
+
+	if err := store.UpsertUserGoals(context.Background(), alice.ID, 2000, 100, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	c = bottest.NewMessage(alice, "/get")
+	if err := h.HandleGet(c); err != nil {
+		t.Fatalf("HandleGet failed: %v", err)
+	}
+
+	goals := "\n\n🎯 <b>Goals</b>\n<pre>" +
+		"kcal       784/2000  ████░░░░░░  39% · 1216 left\n" +
+		"protein     74/100   ███████░░░  74% · 26g left\n" +
+		"</pre>"
+	if got := c.LastSent().Text; got != want+goals {
+		t.Errorf("reply with goals:\n%s\nwant:\n%s", got, want+goals)
+	}
+}
+
+func TestGetDays(t *testing.T) {
+	h, store := newTestHandler()
+	if err := store.UpsertUserTimezone(context.Background(), alice.ID, "UTC"); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	apple := addProduct(t, store, "Apple", 52, 0, 0, 14, models.UnitPiece)
+	yesterday := now.Add(-24 * time.Hour)
+	addRecord(t, store, apple, 2, models.MealSnack, yesterday)
+	addRecord(t, store, apple, 1, models.MealSnack, now)
+
+	// The day before yesterday has no records and does not count towards
+	// the average.
+	c := bottest.NewMessage(alice, "/get 3")
+	if err := h.HandleGet(c); err != nil {
+		t.Fatalf("HandleGet failed: %v", err)
+	}
+
+	got := c.LastSent().Text
+	for _, want := range []string{
+		"<b>Records for the last 3 days:</b>\n\n<pre>📅 " + yesterday.Format("02-01") + "\n",
+		"<pre>📅 " + now.Format("02-01") + "\n",
+		"\n📋 <b>Total: 156 kcal · P 0 g · F 0 g · C 42 g</b>",
+		"\n📊 Daily average: 78 kcal · P 0 g · F 0 g · C 21 g",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("reply:\n%s\nmissing:\n%s", got, want)
+		}
+	}
+	if strings.Contains(got, "Meals") {
+		t.Errorf("reply for several days has a meal breakdown:\n%s", got)
+	}
+}
+
+func TestEditRecord(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"150g", "✏️ Updated: Oatmeal (150 g)\n📊 Calories: 525"},
+		{"700kcal", "✏️ Updated: Oatmeal (200 g)\n📊 Calories: 700"},
+		{"2pcs", "Oatmeal is measured in g, not pcs"},
+		{"lots", "Invalid amount. Use e.g. 200g, 2pcs or 350kcal"},
+		{"0kcal", "Calories must be a positive number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			h, store := newTestHandler()
+			oatmeal := addProduct(t, store, "Oatmeal", 350, 12, 6, 60, models.UnitGram)
+			record := addRecord(t, store, oatmeal, 100, models.MealBreakfast, time.Now())
+
+			c := bottest.NewMessage(alice, "/edit "+record.UUID.String()+" "+tt.value)
+			if err := h.HandleEdit(c); err != nil {
+				t.Fatalf("HandleEdit failed: %v", err)
+			}
+			if got := c.LastSent().Text; got != tt.want {
+				t.Errorf("reply:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditRecordCallback(t *testing.T) {
+	h, store := newTestHandler()
+	oatmeal := addProduct(t, store, "Oatmeal", 350, 12, 6, 60, models.UnitGram)
+	record := addRecord(t, store, oatmeal, 100, models.MealBreakfast, time.Now())
+
+	c := bottest.NewCallback(bob, record.UUID.String())
+	if err := h.HandleEditCallback(c); err != nil {
+		t.Fatalf("HandleEditCallback failed: %v", err)
+	}
+	if got := c.LastResponse(); got == nil || got.Text != "Record not found" {
+		t.Errorf("response to another user %+v, want Record not found", got)
+	}
+
+	c = bottest.NewCallback(alice, record.UUID.String())
+	if err := h.HandleEditCallback(c); err != nil {
+		t.Fatalf("HandleEditCallback failed: %v", err)
+	}
+	if len(c.Responses) != 1 {
+		t.Errorf("answered the callback %d times, want once", len(c.Responses))
+	}
+	if got, want := c.LastSent().Text, "Send the new amount (e.g. 200g) or calories (e.g. 350kcal), /cancel to keep it"; got != want {
+		t.Errorf("prompt %q, want %q", got, want)
+	}
+
+	c = bottest.NewMessage(alice, "250g")
+	if err := h.conversations.HandleText(c); err != nil {
+		t.Fatalf("HandleText failed: %v", err)
+	}
+	if got, want := c.LastSent().Text, "✏️ Updated: Oatmeal (250 g)\n📊 Calories: 875"; got != want {
+		t.Errorf("reply %q, want %q", got, want)
+	}
+}
+
+func TestDeleteRecord(t *testing.T) {
+	h, store := newTestHandler()
+	apple := addProduct(t, store, "Apple", 52, 0, 0, 14, models.UnitPiece)
+	record := addRecord(t, store, apple, 1, models.MealSnack, time.Now())
+
+	c := bottest.NewMessage(bob, "/delete "+record.UUID.String())
+	if err := h.HandleDelete(c); err != nil {
+		t.Fatalf("HandleDelete failed: %v", err)
+	}
+	if got := c.LastSent().Text; got != "Record not found" {
+		t.Errorf("reply to another user %q, want Record not found", got)
+	}
+
+	c = bottest.NewMessage(alice, "/delete "+record.UUID.String())
+	if err := h.HandleDelete(c); err != nil {
+		t.Fatalf("HandleDelete failed: %v", err)
+	}
+	if got := c.LastSent().Text; got != "🗑 Record deleted" {
+		t.Errorf("reply %q, want 🗑 Record deleted", got)
+	}
+	if _, err := store.GetRecordByUUID(context.Background(), record.UUID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("record still stored: %v", err)
+	}
+}
+
+func TestDeleteRecordCallback(t *testing.T) {
+	h, store := newTestHandler()
+	apple := addProduct(t, store, "Apple", 52, 0, 0, 14, models.UnitPiece)
+	record := addRecord(t, store, apple, 1, models.MealSnack, time.Now())
+
+	c := bottest.NewCallback(alice, record.UUID.String())
+	if err := h.HandleDeleteCallback(c); err != nil {
+		t.Fatalf("HandleDeleteCallback failed: %v", err)
+	}
+	if got := c.LastResponse(); got == nil || got.Text != "Record deleted" {
+		t.Errorf("response %+v, want Record deleted", got)
+	}
+	if len(c.Edited) != 1 || !strings.HasPrefix(c.LastEdited().Text, "🗑 ") {
+		t.Errorf("edits %+v, want the message marked as deleted", c.Edited)
+	}
+
+	c = bottest.NewCallback(alice, record.UUID.String())
+	if err := h.HandleDeleteCallback(c); err != nil {
+		t.Fatalf("HandleDeleteCallback failed: %v", err)
+	}
+	if got := c.LastResponse(); got == nil || got.Text != "Record not found" {
+		t.Errorf("response to a second delete %+v, want Record not found", got)
+	}
+}
+
+func TestStatsBestDay(t *testing.T) {
+	h, store := newTestHandler()
+	if err := store.UpsertUserTimezone(context.Background(), alice.ID, "UTC"); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	yesterday := now.Add(-24 * time.Hour)
+	apple := addProduct(t, store, "Apple", 52, 0, 0, 14, models.UnitPiece)
+	addRecord(t, store, apple, 2, models.MealSnack, yesterday)
+	addRecord(t, store, apple, 1, models.MealSnack, now)
+
+	c := bottest.NewMessage(alice, "/stats")
+	if err := h.HandleStats(c); err != nil {
+		t.Fatalf("HandleStats failed: %v", err)
+	}
+	// Without a goal the lightest day is the best.
+	got := c.LastSent().Text
+	for _, want := range []string{
+		"🏆 Best day: " + now.Format("02-01") + " · 52 kcal\n",
+		"📉 Worst day: " + yesterday.Format("02-01") + " · 104 kcal\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("reply without a goal:\n%s\nmissing:\n%s", got, want)
+		}
+	}
+
+	if err := store.UpsertUserGoals(context.Background(), alice.ID, 100, 0, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	c = bottest.NewMessage(alice, "/stats")
+	if err := h.HandleStats(c); err != nil {
+		t.Fatalf("HandleStats failed: %v", err)
+	}
+	got = c.LastSent().Text
+	for _, want := range []string{
+		"🏆 Best day: " + yesterday.Format("02-01") + " · 104 kcal\n",
+		"📉 Worst day: " + now.Format("02-01") + " · 52 kcal\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("reply:\n%s\nmissing:\n%s", got, want)
+		}
+	}
+}
+
+func TestRecipeFollowsIngredients(t *testing.T) {
+	h, store := newTestHandler()
+	ctx := context.Background()
+
+	rice := addProduct(t, store, "Rice", 130, 3, 0, 28, models.UnitGram)
+	pilaf := &models.Recipe{Product: &models.ProductDetails{Name: "Pilaf"}}
+	pilaf.SetIngredient(rice, 200)
+	pilaf.Compute()
+	pilaf, err := store.CreateRecipe(ctx, alice.ID, pilaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bowl := &models.Recipe{Product: &models.ProductDetails{Name: "Bowl"}}
+	bowl.SetIngredient(pilaf.Product, 100)
+	bowl.Compute()
+	if _, err := store.CreateRecipe(ctx, alice.ID, bowl); err != nil {
+		t.Fatal(err)
+	}
+	eaten := addRecord(t, store, pilaf.Product, 300, models.MealLunch, time.Now().Add(-time.Hour))
+
+	// New nutrition for the rice changes the pilaf, and the bowl made of it.
+	if err := h.HandleRecord(bottest.NewMessage(alice, "/record Rice 100g 200")); err != nil {
+		t.Fatalf("HandleRecord failed: %v", err)
+	}
+	for _, name := range []string{"Pilaf", "Bowl"} {
+		recipe, err := store.GetRecipeByName(ctx, alice.ID, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if recipe.Product.Ccal != 200 {
+			t.Errorf("%s has %d kcal per 100 g, want 200", name, recipe.Product.Ccal)
+		}
+	}
+
+	// The pilaf eaten before keeps its nutrition.
+	eaten, err = store.GetRecordByUUID(ctx, eaten.UUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kcal := eaten.Nutrition().Kcal; kcal != 390 {
+		t.Errorf("pilaf eaten before has %v kcal, want 390", kcal)
+	}
+
+	// Without a cooked weight given the dish weighs as much as the rice.
+	if err := h.HandleRecipe(bottest.NewMessage(alice, "/recipe edit Pilaf")); err != nil {
+		t.Fatalf("HandleRecipe failed: %v", err)
+	}
+	for _, text := range []string{"rice 400g", "done"} {
+		if err := h.conversations.HandleText(bottest.NewMessage(alice, text)); err != nil {
+			t.Fatalf("%q failed: %v", text, err)
+		}
+	}
+	recipe, err := store.GetRecipeByName(ctx, alice.ID, "Pilaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipe.CookedWeight != 0 || recipe.Weight() != 400 || recipe.Product.Ccal != 200 {
+		t.Errorf("pilaf weighs %v (cooked weight %v) with %d kcal per 100 g, want 400 (none) with 200",
+			recipe.Weight(), recipe.CookedWeight, recipe.Product.Ccal)
+	}
+}
+
+func TestRecipeCycle(t *testing.T) {
+	h, store := newTestHandler()
+	ctx := context.Background()
+
+	rice := addProduct(t, store, "Rice", 130, 3, 0, 28, models.UnitGram)
+	pilaf := &models.Recipe{Product: &models.ProductDetails{Name: "Pilaf"}}
+	pilaf.SetIngredient(rice, 200)
+	pilaf.Compute()
+	pilaf, err := store.CreateRecipe(ctx, alice.ID, pilaf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bowl := &models.Recipe{Product: &models.ProductDetails{Name: "Bowl"}}
+	bowl.SetIngredient(pilaf.Product, 100)
+	bowl.Compute()
+	if _, err := store.CreateRecipe(ctx, alice.ID, bowl); err != nil {
+		t.Fatal(err)
+	}
+
+	// The bowl is made of the pilaf, so the pilaf cannot be made of the bowl.
+	if err := h.HandleRecipe(bottest.NewMessage(alice, "/recipe edit Pilaf")); err != nil {
+		t.Fatalf("HandleRecipe failed: %v", err)
+	}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"bowl 100g", "Bowl is made of this recipe, so it cannot be its ingredient"},
+		{"pilaf 100g", "A recipe cannot contain itself"},
+	}
+	for _, tt := range tests {
+		c := bottest.NewMessage(alice, tt.text)
+		if err := h.conversations.HandleText(c); err != nil {
+			t.Fatalf("%q failed: %v", tt.text, err)
+		}
+		if got := c.LastSent().Text; got != tt.want {
+			t.Errorf("reply to %q: %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	recipe, err := store.GetRecipeByName(ctx, alice.ID, "Pilaf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recipe.Ingredients) != 1 {
+		t.Errorf("pilaf has %d ingredients, want 1", len(recipe.Ingredients))
+	}
+}
+
+func TestBarcodeOnlyInPrivateChats(t *testing.T) {
+	h, _ := newTestHandler()
+
+	group := &tele.Chat{ID: -100, Type: tele.ChatGroup}
+	photo := bottest.New(tele.Update{Message: &tele.Message{
+		ID: 1, Sender: alice, Chat: group, Photo: &tele.Photo{File: tele.File{FileID: "photo"}},
+	}})
+	if err := h.HandlePhoto(photo); err != nil {
+		t.Fatalf("HandlePhoto failed: %v", err)
+	}
+	digits := bottest.New(tele.Update{Message: &tele.Message{
+		ID: 1, Sender: alice, Chat: group, Text: "4006381333931",
+	}})
+	if err := h.HandleBarcodeText(digits); err != nil {
+		t.Fatalf("HandleBarcodeText failed: %v", err)
+	}
+	if len(photo.Sent) != 0 || len(digits.Sent) != 0 {
+		t.Errorf("replied in a group: %q, %q", photo.LastSent().Text, digits.LastSent().Text)
+	}
+
+	c := bottest.NewMessage(alice, "4006381333931")
+	if err := h.HandleBarcodeText(c); err != nil {
+		t.Fatalf("HandleBarcodeText failed: %v", err)
+	}
+	if want := "🤷 No product with barcode 4006381333931 in the catalog."; !strings.HasPrefix(c.LastSent().Text, want) {
+		t.Errorf("reply in a private chat:\n%s\nwant prefix:\n%s", c.LastSent().Text, want)
+	}
+}
@@ -0,0 +1,136 @@
+package bot
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/models"
+	"backend/internal/timeout"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+const inlineLimit = 20
+
+// HandleQuery answers inline queries ("@bot chicken 250g") with matching
+// common items and catalog products of the user.
+func (h *BotHandler) HandleQuery(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	name, amount, unit := parseInlineQuery(c.Query().Text)
+
+	userID := identity.From(c)
+
+	var results tele.Results
+	seen := make(map[uuid.UUID]bool)
+
+	addResult := func(title string, product *models.ProductDetails) {
+		if seen[product.UUID] || len(results) >= inlineLimit {
+			return
+		}
+		seen[product.UUID] = true
+
+		portion, err := productAmount(p, product, amount, unit)
+		if err != nil {
+			return
+		}
+		nutrition := product.Nutrition(portion)
+
+		result := &tele.ArticleResult{
+			Title:       title,
+			Description: p.Sprintf("%s · %.0f kcal", models.FormatAmount(portion, p.T(product.Unit)), nutrition.Kcal),
+			Text: p.Sprintf("🍽️ %s — %s · %.0f kcal",
+				product.Name, models.FormatAmount(portion, p.T(product.Unit)), nutrition.Kcal),
+		}
+		result.SetResultID(product.UUID.String())
+		results = append(results, result)
+	}
+
+	if name != "" {
+		items, err := h.db.SearchUserCommonItemsByName(ctx, userID, name, inlineLimit)
+		if err != nil {
+			log.Printf("Error searching common items: %v", err)
+		}
+		for _, item := range items {
+			product, err := h.db.GetProductByUUID(ctx, *item.ProductUUID)
+			if err != nil {
+				log.Printf("Error getting product %s: %v", *item.ProductUUID, err)
+				continue
+			}
+			addResult(item.Name, product)
+		}
+
+		products, err := h.db.SearchProductsByName(ctx, userID, name, inlineLimit)
+		if err != nil {
+			log.Printf("Error searching products: %v", err)
+		}
+		for _, product := range products {
+			addResult(product.Name, product)
+		}
+	}
+
+	return c.Answer(&tele.QueryResponse{
+		Results:    results,
+		CacheTime:  0,
+		IsPersonal: true,
+	})
+}
+
+// HandleInlineResult records the product the user picked from the inline
+// results. Telegram only sends these updates when inline feedback is
+// enabled for the bot in @BotFather.
+func (h *BotHandler) HandleInlineResult(c tele.Context) error {
+	ctx := timeout.From(c)
+	result := c.InlineResult()
+
+	productUUID, err := uuid.Parse(result.ResultID)
+	if err != nil {
+		log.Printf("Invalid inline result id %q", result.ResultID)
+		return nil
+	}
+
+	userID := identity.From(c)
+
+	product, err := h.db.GetProductByUUID(ctx, productUUID)
+	if err != nil {
+		log.Printf("Error getting product %s: %v", productUUID, err)
+		return nil
+	}
+	if product.Owner != nil && *product.Owner != userID {
+		log.Printf("User %d picked foreign product %s", userID, productUUID)
+		return nil
+	}
+
+	_, amount, unit := parseInlineQuery(result.Query)
+	amount, err = productAmount(i18n.From(c), product, amount, unit)
+	if err != nil {
+		log.Printf("Error resolving inline amount: %v", err)
+		return nil
+	}
+
+	if _, err := h.insertRecord(ctx, userID, product.UUID, amount, "", time.Time{}); err != nil {
+		log.Printf("Error inserting record: %v", err)
+	}
+
+	return nil
+}
+
+// parseInlineQuery splits an inline query into a product name and an
+// optional trailing amount, e.g. "chicken breast 250g".
+func parseInlineQuery(text string) (string, float64, string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", 0, ""
+	}
+
+	amount, unit, err := models.ParseAmount(fields[len(fields)-1])
+	if err != nil {
+		return strings.Join(fields, " "), 0, ""
+	}
+
+	return strings.Join(fields[:len(fields)-1], " "), amount, unit
+}
@@ -0,0 +1,114 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/timeout"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const notifyUsage = "Usage:\n" +
+	"/notify - Show your notification settings\n" +
+	"/notify summary on|off - Daily summary of the previous day at your day flip time\n" +
+	"/notify remind <HH:MM> [HH:MM...] - Remind to log meals at these times if nothing was logged\n" +
+	"/notify remind off - Disable reminders"
+
+func (h *BotHandler) HandleNotify(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	args := c.Args()
+
+	userID := identity.From(c)
+
+	if len(args) == 0 {
+		return h.sendNotifySettings(c, userID)
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "summary":
+		if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+			return c.Send(p.T(notifyUsage))
+		}
+
+		err := h.db.UpsertUserDailySummary(ctx, userID, c.Chat().ID, args[1] == "on")
+		if err != nil {
+			log.Printf("Error setting daily summary: %v", err)
+			return c.Send(p.Sprintf("❌ Error saving notification settings: %v", err))
+		}
+
+		if args[1] == "on" {
+			return c.Send(p.T("✅ You will get a summary of the previous day at your day flip time"))
+		}
+		return c.Send(p.T("✅ Daily summary disabled"))
+
+	case "remind":
+		if len(args) < 2 {
+			return c.Send(p.T(notifyUsage))
+		}
+
+		var reminders []time.Time
+		if !(len(args) == 2 && args[1] == "off") {
+			for _, arg := range args[1:] {
+				reminder, err := time.Parse("15:04", arg)
+				if err != nil {
+					return c.Send(p.Sprintf("Invalid time %q. Use HH:MM (e.g., 13:00)", arg))
+				}
+				reminders = append(reminders, reminder)
+			}
+			sort.Slice(reminders, func(i, j int) bool { return reminders[i].Before(reminders[j]) })
+		}
+
+		err := h.db.UpsertUserReminders(ctx, userID, c.Chat().ID, reminders)
+		if err != nil {
+			log.Printf("Error setting reminders: %v", err)
+			return c.Send(p.Sprintf("❌ Error saving notification settings: %v", err))
+		}
+
+		if len(reminders) == 0 {
+			return c.Send(p.T("✅ Reminders disabled"))
+		}
+		return c.Send(p.Sprintf("✅ Reminders set for %s", formatReminders(reminders)))
+	}
+
+	return c.Send(p.T(notifyUsage))
+}
+
+func (h *BotHandler) sendNotifySettings(c tele.Context, userID int64) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	schedule, err := h.db.GetUserSchedule(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Send(p.T("🔕 No notifications set up\n\n") + p.T(notifyUsage))
+	}
+	if err != nil {
+		log.Printf("Error getting schedule: %v", err)
+		return c.Send(p.Sprintf("❌ Error loading notification settings: %v", err))
+	}
+
+	summary := p.T("off")
+	if schedule.DailySummary {
+		summary = p.T("on")
+	}
+	reminders := p.T("off")
+	if len(schedule.Reminders) > 0 {
+		reminders = formatReminders(schedule.Reminders)
+	}
+
+	return c.Send(p.Sprintf("🔔 Daily summary: %s\n⏰ Reminders: %s\n\n%s", summary, reminders, p.T(notifyUsage)))
+}
+
+func formatReminders(reminders []time.Time) string {
+	times := make([]string, len(reminders))
+	for i, reminder := range reminders {
+		times[i] = reminder.Format("15:04")
+	}
+	return strings.Join(times, ", ")
+}
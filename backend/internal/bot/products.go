@@ -0,0 +1,109 @@
+package bot
+
+import (
+	"bytes"
+	"log"
+	"strings"
+
+	"backend/internal/catalog"
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/storage"
+	"backend/internal/timeout"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// maxImportSize is the largest file the Bot API lets bots download.
+const maxImportSize = 20 << 20
+
+// importUsage takes the list of columns.
+const importUsage = "Send a .csv or .json file with /import as its caption to add or update products in your catalog.\n" +
+	"Columns: %s\n" +
+	"Only name and ccal are required. Nutrition is per 100 g/ml or per piece, the unit (g, ml or pcs) defaults to g.\n" +
+	"Products with the same barcode or name are updated. Get the current list with /export"
+
+func (h *BotHandler) HandleImport(c tele.Context) error {
+	return c.Send(i18n.From(c).Sprintf(importUsage, strings.Join(catalog.Columns, ",")))
+}
+
+// HandleDocument imports products from a file sent with /import as its
+// caption. Other documents are ignored.
+func (h *BotHandler) HandleDocument(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	if !strings.HasPrefix(strings.TrimSpace(c.Message().Caption), "/import") {
+		return nil
+	}
+
+	doc := c.Message().Document
+	if doc.FileSize > maxImportSize {
+		return c.Send(p.T("The file is too large, the limit is 20 MB"))
+	}
+
+	userID := identity.From(c)
+
+	file, err := c.Bot().File(&doc.File)
+	if err != nil {
+		log.Printf("Error downloading document: %v", err)
+		return c.Send(p.Sprintf("❌ Error downloading file: %v", err))
+	}
+	defer file.Close()
+
+	products, err := catalog.Read(file, catalog.FormatOf(doc.FileName))
+	if err != nil {
+		return c.Send(p.Sprintf("❌ The file was not imported:\n%v", err) + "\n\n" + p.Sprintf(importUsage, strings.Join(catalog.Columns, ",")))
+	}
+
+	created, updated, err := h.db.UpsertProducts(ctx, &userID, products)
+	if err != nil {
+		log.Printf("Error importing products: %v", err)
+		return c.Send(p.Sprintf("❌ Error importing products: %v", err))
+	}
+	if updated > 0 {
+		if err := storage.RefreshRecipes(ctx, h.db, userID); err != nil {
+			log.Printf("Error refreshing recipes: %v", err)
+		}
+	}
+
+	return c.Send(p.Plural(len(products), "✅ Imported %d product: %d new, %d updated", "✅ Imported %d products: %d new, %d updated", len(products), created, updated))
+}
+
+// HandleExport sends the user's own products as a file that /import accepts.
+func (h *BotHandler) HandleExport(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	format := catalog.FormatCSV
+	if args := c.Args(); len(args) > 0 {
+		format = strings.ToLower(args[0])
+		if format != catalog.FormatCSV && format != catalog.FormatJSON {
+			return c.Send(p.T("Usage: /export [csv|json]"))
+		}
+	}
+
+	userID := identity.From(c)
+
+	var buf bytes.Buffer
+	writer, err := catalog.NewWriter(&buf, format)
+	if err == nil {
+		err = h.db.EachProduct(ctx, &userID, writer.Write)
+	}
+	count := 0
+	if err == nil {
+		count, err = writer.Close()
+	}
+	if err != nil {
+		log.Printf("Error exporting products: %v", err)
+		return c.Send(p.Sprintf("❌ Error exporting products: %v", err))
+	}
+
+	if count == 0 {
+		return c.Send(p.T("Your catalog is empty.\n\n") + p.Sprintf(importUsage, strings.Join(catalog.Columns, ",")))
+	}
+
+	return c.Send(&tele.Document{
+		File:     tele.FromReader(&buf),
+		FileName: "products." + format,
+		Caption:  p.Plural(count, "📦 %d product", "📦 %d products"),
+	})
+}
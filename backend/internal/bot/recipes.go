@@ -0,0 +1,308 @@
+package bot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/models"
+	"backend/internal/storage"
+	"backend/internal/timeout"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+const recipeUsage = "Usage:\n" +
+	"/recipe - List your recipes\n" +
+	"/recipe new <name> - Create a recipe from catalog products\n" +
+	"/recipe show <name> - Show ingredients and nutrition per 100 g\n" +
+	"/recipe edit <name> - Change ingredients or cooked weight\n" +
+	"/recipe delete <name> - Delete a recipe (the dish stays in your catalog)\n" +
+	"Log a portion like any product: /record <name> 300g"
+
+const recipeBuilderHelp = "Send ingredients one per message as <product> <amount>, e.g. rice 200g.\n" +
+	"remove <product> - Remove an ingredient\n" +
+	"done [cooked weight] - Save, e.g. done 850g (defaults to the weight of the ingredients)\n" +
+	"/cancel - Stop without saving"
+
+func (h *BotHandler) HandleRecipe(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	args := c.Args()
+
+	userID := identity.From(c)
+
+	if len(args) == 0 {
+		return h.sendRecipes(c, userID)
+	}
+
+	action := strings.ToLower(args[0])
+	name := strings.Trim(strings.Join(args[1:], " "), "\"'")
+	if name == "" {
+		return c.Send(p.T(recipeUsage))
+	}
+
+	if action == "new" {
+		existing, err := h.db.GetProductByName(ctx, userID, name)
+		if err == nil && existing.Owner != nil && *existing.Owner == userID {
+			return c.Send(p.Sprintf("You already have a product named %s. Pick another name", existing.Name))
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error finding product: %v", err)
+			return c.Send(p.Sprintf("❌ Error finding product: %v", err))
+		}
+
+		recipe := &models.Recipe{Product: &models.ProductDetails{Name: name, Unit: models.UnitGram}}
+		h.buildRecipe(c, userID, recipe)
+		return c.Send(p.Sprintf("🍲 New recipe %s\n%s", name, p.T(recipeBuilderHelp)))
+	}
+
+	// Ingredients may have changed since the recipes were saved.
+	if err := storage.RefreshRecipes(ctx, h.db, userID); err != nil {
+		log.Printf("Error refreshing recipes: %v", err)
+	}
+
+	recipe, err := h.db.GetRecipeByName(ctx, userID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Send(p.Sprintf("You have no recipe named %s", name))
+	}
+	if err != nil {
+		log.Printf("Error getting recipe: %v", err)
+		return c.Send(p.Sprintf("❌ Error getting recipe: %v", err))
+	}
+
+	switch action {
+	case "show":
+		return c.Send(recipeMessage(p, recipe), tele.ModeHTML)
+
+	case "edit":
+		h.buildRecipe(c, userID, recipe)
+		return c.Send(recipeMessage(p, recipe)+"\n\n"+html.EscapeString(p.T(recipeBuilderHelp)), tele.ModeHTML)
+
+	case "delete":
+		err := h.db.DeleteRecipe(ctx, userID, recipe.Product.UUID)
+		if err != nil {
+			log.Printf("Error deleting recipe: %v", err)
+			return c.Send(p.Sprintf("❌ Error deleting recipe: %v", err))
+		}
+		return c.Send(p.Sprintf("🗑 Recipe %s deleted. The dish stays in your catalog with its last nutrition", recipe.Product.Name))
+	}
+
+	return c.Send(p.T(recipeUsage))
+}
+
+func (h *BotHandler) sendRecipes(c tele.Context, userID int64) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	dishes, err := h.db.GetRecipesByUser(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting recipes: %v", err)
+		return c.Send(p.Sprintf("❌ Error getting recipes: %v", err))
+	}
+
+	if len(dishes) == 0 {
+		return c.Send(p.T("You have no recipes yet.\n\n") + p.T(recipeUsage))
+	}
+
+	var message strings.Builder
+	message.WriteString(p.T("🍲 Your recipes:\n"))
+	for _, dish := range dishes {
+		message.WriteString(p.Sprintf("• %s - %d kcal per 100 g\n", dish.Name, dish.Ccal))
+	}
+	message.WriteString("\n" + p.T(recipeUsage))
+
+	return c.Send(message.String())
+}
+
+// buildRecipe waits for ingredient changes to recipe until the user saves
+// it with "done". New recipes have no dish UUID yet.
+func (h *BotHandler) buildRecipe(c tele.Context, userID int64, recipe *models.Recipe) {
+	p := i18n.From(c)
+	var step func(c tele.Context) error
+	step = func(c tele.Context) error {
+		ctx := timeout.From(c)
+		fields := strings.Fields(c.Text())
+		if len(fields) == 0 {
+			h.conversations.Expect(c.Sender().ID, step)
+			return c.Send(p.T(recipeBuilderHelp))
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case "done":
+			if len(fields) > 2 {
+				h.conversations.Expect(c.Sender().ID, step)
+				return c.Send(p.T("Usage: done [cooked weight], e.g. done 850g"))
+			}
+			if len(fields) == 2 {
+				weight, unit, err := models.ParseAmount(fields[1])
+				if err != nil || (unit != "" && unit != models.UnitGram) {
+					h.conversations.Expect(c.Sender().ID, step)
+					return c.Send(p.T("The cooked weight must be in grams, e.g. done 850g"))
+				}
+				recipe.CookedWeight = weight
+			}
+			return h.saveRecipe(c, userID, recipe, step)
+
+		case "remove":
+			name := strings.Join(fields[1:], " ")
+			for i, ingredient := range recipe.Ingredients {
+				if strings.EqualFold(ingredient.Product.Name, name) {
+					recipe.Ingredients = append(recipe.Ingredients[:i], recipe.Ingredients[i+1:]...)
+					h.conversations.Expect(c.Sender().ID, step)
+					return c.Send(p.Sprintf("➖ %s removed\n%s", ingredient.Product.Name, recipeTotal(p, recipe)))
+				}
+			}
+			h.conversations.Expect(c.Sender().ID, step)
+			return c.Send(p.Sprintf("%s is not an ingredient of this recipe", name))
+		}
+
+		h.conversations.Expect(c.Sender().ID, step)
+
+		if len(fields) < 2 {
+			return c.Send(p.T(recipeBuilderHelp))
+		}
+		amount, unit, err := models.ParseAmount(fields[len(fields)-1])
+		if err != nil {
+			return c.Send(p.T(err.Error()) + "\n" + p.T(recipeBuilderHelp))
+		}
+		name := strings.Join(fields[:len(fields)-1], " ")
+
+		product, err := h.findIngredient(ctx, userID, name)
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Send(h.unknownIngredientMessage(ctx, p, userID, name))
+		}
+		if err != nil {
+			log.Printf("Error finding product: %v", err)
+			return c.Send(p.Sprintf("❌ Error finding product: %v", err))
+		}
+		cycle, err := storage.LeadsTo(ctx, h.db, userID, product.UUID, recipe.Product.UUID)
+		if err != nil {
+			log.Printf("Error checking recipe %s: %v", product.Name, err)
+			return c.Send(p.Sprintf("❌ Error finding product: %v", err))
+		}
+		if cycle && product.UUID == recipe.Product.UUID {
+			return c.Send(p.T("A recipe cannot contain itself"))
+		}
+		if cycle {
+			return c.Send(p.Sprintf("%s is made of this recipe, so it cannot be its ingredient", product.Name))
+		}
+
+		amount, err = productAmount(p, product, amount, unit)
+		if err != nil {
+			return c.Send(err.Error())
+		}
+
+		recipe.SetIngredient(product, amount)
+		return c.Send(p.Sprintf("➕ %s (%s)\n%s",
+			product.Name, models.FormatAmount(amount, p.T(product.Unit)), recipeTotal(p, recipe)))
+	}
+
+	h.conversations.Expect(c.Sender().ID, step)
+}
+
+// saveRecipe computes the dish nutrition and stores the recipe, asking for
+// more input through step when it is incomplete.
+func (h *BotHandler) saveRecipe(c tele.Context, userID int64, recipe *models.Recipe, step func(c tele.Context) error) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	if len(recipe.Ingredients) == 0 {
+		h.conversations.Expect(c.Sender().ID, step)
+		return c.Send(p.T("Add at least one ingredient first"))
+	}
+
+	if recipe.Weight() == 0 {
+		h.conversations.Expect(c.Sender().ID, step)
+		return c.Send(p.T("Send the cooked weight of the dish, e.g. done 850g"))
+	}
+
+	recipe.Compute()
+
+	var err error
+	if recipe.Product.UUID == uuid.Nil {
+		recipe, err = h.db.CreateRecipe(ctx, userID, recipe)
+	} else {
+		err = h.db.UpdateRecipe(ctx, userID, recipe)
+	}
+	if err != nil {
+		log.Printf("Error saving recipe: %v", err)
+		h.conversations.Expect(c.Sender().ID, step)
+		return c.Send(p.Sprintf("❌ Error saving recipe: %v", err))
+	}
+
+	// Dishes made of this one change with it.
+	if err := storage.RefreshRecipes(ctx, h.db, userID); err != nil {
+		log.Printf("Error refreshing recipes: %v", err)
+	}
+
+	return c.Send(p.Sprintf("✅ Recipe saved\n\n%s\n\nLog a portion: /record %s 300g",
+		recipeMessage(p, recipe), html.EscapeString(recipe.Product.Name)), tele.ModeHTML)
+}
+
+// findIngredient resolves an ingredient name to a product visible to userID,
+// preferring an exact name over the best fuzzy match.
+func (h *BotHandler) findIngredient(ctx context.Context, userID int64, name string) (*models.ProductDetails, error) {
+	product, err := h.db.GetProductByName(ctx, userID, name)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return product, err
+	}
+
+	products, err := h.db.SearchProductsByName(ctx, userID, name, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return products[0], nil
+}
+
+func (h *BotHandler) unknownIngredientMessage(ctx context.Context, p *i18n.Printer, userID int64, name string) string {
+	message := p.Sprintf("🤷 \"%s\" is not in your catalog yet. Add it with /record first", name)
+
+	similar, err := h.db.SearchProductsByName(ctx, userID, name, 5)
+	if err != nil {
+		log.Printf("Error searching products: %v", err)
+	}
+	for i, product := range similar {
+		if i == 0 {
+			message += p.T("\n\nSimilar products:")
+		}
+		message += "\n• " + product.Name
+	}
+
+	return message
+}
+
+func recipeTotal(p *i18n.Printer, recipe *models.Recipe) string {
+	total := recipe.Total()
+	return p.Sprintf("Total: %.0f kcal · P %.0f · F %.0f · C %.0f · %s raw",
+		total.Kcal, total.Proteins, total.Fats, total.Carbs, models.FormatAmount(recipe.RawWeight(), p.T(models.UnitGram)))
+}
+
+func recipeMessage(p *i18n.Printer, recipe *models.Recipe) string {
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("🍲 <b>%s</b>\n", html.EscapeString(recipe.Product.Name)))
+	for _, ingredient := range recipe.Ingredients {
+		kcal := ingredient.Product.Nutrition(ingredient.Amount).Kcal
+		message.WriteString(p.Sprintf("• %s - %s (%.0f kcal)\n",
+			html.EscapeString(ingredient.Product.Name), models.FormatAmount(ingredient.Amount, p.T(ingredient.Product.Unit)), kcal))
+	}
+
+	message.WriteString(html.EscapeString(recipeTotal(p, recipe)) + "\n")
+	if weight := recipe.Weight(); weight > 0 {
+		message.WriteString(p.Sprintf("Cooked weight: %s\n", models.FormatAmount(weight, p.T(models.UnitGram))))
+	}
+	if recipe.Product.Ccal > 0 {
+		message.WriteString(p.Sprintf("Per 100 g: %d kcal · P %d · F %d · C %d",
+			recipe.Product.Ccal, recipe.Product.Proteins, recipe.Product.Fats, recipe.Product.Carbs))
+	}
+
+	return strings.TrimSuffix(message.String(), "\n")
+}
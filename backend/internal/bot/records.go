@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/models"
+	"backend/internal/timeout"
+
+	"github.com/google/uuid"
+	tele "gopkg.in/telebot.v3"
+)
+
+func (h *BotHandler) HandleEdit(c tele.Context) error {
+	p := i18n.From(c)
+	args := c.Args()
+	if len(args) < 2 {
+		return c.Send(p.T("Usage: /edit <id> <amount|kcal>\nExample: /edit <id> 200g or /edit <id> 350kcal"))
+	}
+
+	recordUUID, err := uuid.Parse(args[0])
+	if err != nil {
+		return c.Send(p.T("Invalid record ID"))
+	}
+
+	userID := identity.From(c)
+
+	return h.editRecord(c, userID, recordUUID, args[1])
+}
+
+func (h *BotHandler) HandleDelete(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	args := c.Args()
+	if len(args) == 0 {
+		return c.Send(p.T("Usage: /delete <id>"))
+	}
+
+	recordUUID, err := uuid.Parse(args[0])
+	if err != nil {
+		return c.Send(p.T("Invalid record ID"))
+	}
+
+	userID := identity.From(c)
+
+	err = h.db.DeleteRecord(ctx, userID, recordUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Send(p.T("Record not found"))
+	}
+	if err != nil {
+		log.Printf("Error deleting record: %v", err)
+		return c.Send(p.Sprintf("❌ Error deleting record: %v", err))
+	}
+
+	return c.Send(p.T("🗑 Record deleted"))
+}
+
+func (h *BotHandler) HandleEditCallback(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	recordUUID, err := uuid.Parse(c.Callback().Data)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Invalid record")})
+	}
+
+	userID := identity.From(c)
+
+	record, err := h.db.GetRecordByUUID(ctx, recordUUID)
+	if err != nil || record.UserID != userID {
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Record not found")})
+	}
+
+	h.conversations.Expect(c.Sender().ID, func(c tele.Context) error {
+		return h.editRecord(c, userID, recordUUID, strings.TrimSpace(c.Text()))
+	})
+
+	if err := c.Respond(); err != nil {
+		log.Printf("Error answering callback: %v", err)
+	}
+	return c.Send(p.T("Send the new amount (e.g. 200g) or calories (e.g. 350kcal), /cancel to keep it"))
+}
+
+func (h *BotHandler) HandleDeleteCallback(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	recordUUID, err := uuid.Parse(c.Callback().Data)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Invalid record")})
+	}
+
+	userID := identity.From(c)
+
+	err = h.db.DeleteRecord(ctx, userID, recordUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Record not found")})
+	}
+	if err != nil {
+		log.Printf("Error deleting record: %v", err)
+		return c.Respond(&tele.CallbackResponse{Text: p.T("Error deleting record")})
+	}
+
+	if err := c.Edit("🗑 " + c.Message().Text); err != nil {
+		log.Printf("Error editing message: %v", err)
+	}
+	return c.Respond(&tele.CallbackResponse{Text: p.T("Record deleted")})
+}
+
+// editRecord sets the amount of a record from value, which is either an
+// amount ("200g", "2") or an energy ("350kcal") converted to an amount of
+// the record's product.
+func (h *BotHandler) editRecord(c tele.Context, userID int64, recordUUID uuid.UUID, value string) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	record, err := h.db.GetRecordByUUID(ctx, recordUUID)
+	if err != nil || record.UserID != userID {
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Error getting record %s: %v", recordUUID, err)
+		}
+		return c.Send(p.T("Record not found"))
+	}
+
+	product, err := h.db.GetProductByUUID(ctx, record.ProductUUID)
+	if err != nil {
+		log.Printf("Error getting product %s: %v", record.ProductUUID, err)
+		return c.Send(p.Sprintf("❌ Error loading product: %v", err))
+	}
+	product = record.Recorded(product)
+
+	amount, err := parseEditValue(p, product, value)
+	if err != nil {
+		return c.Send(err.Error())
+	}
+
+	record, err = h.db.UpdateRecordAmount(ctx, userID, recordUUID, amount)
+	if err != nil {
+		log.Printf("Error updating record: %v", err)
+		return c.Send(p.Sprintf("❌ Error updating record: %v", err))
+	}
+
+	nutrition := record.Nutrition()
+	return c.Send(p.Sprintf("✏️ Updated: %s (%s)\n📊 Calories: %.0f",
+		product.Name, models.FormatAmount(record.Amount, p.T(product.Unit)), nutrition.Kcal))
+}
+
+func parseEditValue(p *i18n.Printer, product *models.ProductDetails, value string) (float64, error) {
+	lower := strings.ToLower(value)
+	if strings.HasSuffix(lower, "kcal") {
+		kcal, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(lower, "kcal")), 64)
+		if err != nil || kcal <= 0 {
+			return 0, errors.New(p.T("Calories must be a positive number"))
+		}
+
+		amount := math.Round(kcal/product.Nutrition(1).Kcal*1000) / 1000
+		if amount <= 0 {
+			return 0, errors.New(p.T("Calories are too low for this product"))
+		}
+		return amount, nil
+	}
+
+	amount, unit, err := models.ParseAmount(value)
+	if err != nil {
+		return 0, errors.New(p.T("Invalid amount. Use e.g. 200g, 2pcs or 350kcal"))
+	}
+
+	return productAmount(p, product, amount, unit)
+}
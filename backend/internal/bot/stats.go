@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/i18n"
+	"backend/internal/identity"
+	"backend/internal/report"
+	"backend/internal/timeout"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// maxStatsDays is the longest period /stats covers.
+const maxStatsDays = 365
+
+func (h *BotHandler) HandleStats(c tele.Context) error {
+	p := i18n.From(c)
+	ctx := timeout.From(c)
+	days := 7
+	args := c.Args()
+	if len(args) > 0 {
+		switch strings.ToLower(args[0]) {
+		case "week":
+			days = 7
+		case "month":
+			days = 30
+		default:
+			var err error
+			days, err = strconv.Atoi(args[0])
+			if err != nil || days <= 0 || days > maxStatsDays {
+				return c.Send(p.Sprintf("Usage: /stats [week|month|N]\nN is a number of days from 1 to %d\n"+
+					"Example: /stats month or /stats 14", maxStatsDays))
+			}
+		}
+	}
+
+	userID := identity.From(c)
+
+	prefs := h.preferences(ctx, userID)
+	now := time.Now().In(prefs.Location())
+	period := report.LastDays(now, prefs.Noon, days)
+
+	totals, err := h.db.GetDailyTotals(ctx, userID, period[0].Start, now, prefs.Location().String(), prefs.Noon)
+	if err != nil {
+		log.Printf("Error getting daily totals: %v", err)
+		return c.Send(p.Sprintf("❌ Error fetching statistics: %v", err))
+	}
+
+	stats := report.ComputeStats(period, totals, prefs.GoalKcal)
+	title := p.Plural(days, "📊 Statistics for the last day\n\n", "📊 Statistics for the last %d days\n\n")
+	return c.Send(title + stats.Format(p))
+}
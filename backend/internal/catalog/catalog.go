@@ -0,0 +1,331 @@
+// Package catalog reads and writes product lists as CSV or JSON files, e.g.
+// a canteen's nutrition sheet exported from a spreadsheet.
+package catalog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"backend/internal/barcode"
+	"backend/internal/models"
+)
+
+// File formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// Columns are the CSV columns and JSON keys of a product. Only name and
+// ccal are required; nutrition is per 100 g/ml or per piece as in the
+// catalog, and the unit defaults to grams.
+var Columns = []string{"name", "ccal", "proteins", "fats", "carbs", "unit", "brand", "barcode"}
+
+// maxErrors is how many invalid rows are reported before giving up.
+const maxErrors = 10
+
+// maxNutrition bounds every nutrition value of a row. It is far above any
+// real product, so it only rejects typos and numbers that would overflow.
+const maxNutrition = 100000
+
+// Row is a product as it appears in a file.
+type Row struct {
+	Name     string  `json:"name"`
+	Ccal     float64 `json:"ccal"`
+	Proteins float64 `json:"proteins"`
+	Fats     float64 `json:"fats"`
+	Carbs    float64 `json:"carbs"`
+	Unit     string  `json:"unit,omitempty"`
+	Brand    string  `json:"brand,omitempty"`
+	Barcode  string  `json:"barcode,omitempty"`
+}
+
+// FormatOf picks the format of a file by its name.
+func FormatOf(name string) string {
+	if strings.HasSuffix(strings.ToLower(name), ".json") {
+		return FormatJSON
+	}
+	return FormatCSV
+}
+
+// Read parses a product file. Every row is validated against the catalog's
+// constraints and the file is rejected as a whole, listing the first
+// invalid rows, if any of them is wrong.
+func Read(r io.Reader, format string) ([]*models.ProductDetails, error) {
+	var rows []Row
+	var lines []int
+	var err error
+
+	switch format {
+	case FormatCSV:
+		rows, lines, err = readCSV(r)
+	case FormatJSON:
+		rows, err = readJSON(r)
+	default:
+		err = fmt.Errorf("unknown format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var products []*models.ProductDetails
+	var problems []string
+	for i, row := range rows {
+		product, err := row.Product()
+		if err != nil {
+			position := fmt.Sprintf("item %d", i+1)
+			if lines != nil {
+				position = fmt.Sprintf("line %d", lines[i])
+			}
+			problems = append(problems, fmt.Sprintf("%s: %v", position, err))
+			if len(problems) == maxErrors {
+				problems = append(problems, "...")
+				break
+			}
+			continue
+		}
+		products = append(products, product)
+	}
+
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	if len(products) == 0 {
+		return nil, errors.New("no products in the file")
+	}
+
+	return products, nil
+}
+
+// Product validates the row and converts it to a catalog product. Amounts
+// are rounded to whole numbers as the catalog stores them.
+func (r Row) Product() (*models.ProductDetails, error) {
+	name := strings.TrimSpace(r.Name)
+	switch {
+	case name == "":
+		return nil, errors.New("name is required")
+	case utf8.RuneCountInString(name) > 255:
+		return nil, errors.New("name is longer than 255 characters")
+	case utf8.RuneCountInString(r.Brand) > 255:
+		return nil, errors.New("brand is longer than 255 characters")
+	}
+
+	ccal := math.Round(r.Ccal)
+	if ccal <= 0 {
+		return nil, errors.New("ccal must be positive")
+	}
+	for _, value := range []struct {
+		name  string
+		value float64
+	}{{"ccal", r.Ccal}, {"proteins", r.Proteins}, {"fats", r.Fats}, {"carbs", r.Carbs}} {
+		if value.value < 0 {
+			return nil, fmt.Errorf("%s must not be negative", value.name)
+		}
+		// Written this way round to catch NaN as well.
+		if !(value.value <= maxNutrition) {
+			return nil, fmt.Errorf("%s must be at most %d", value.name, maxNutrition)
+		}
+	}
+
+	unit := models.UnitGram
+	if strings.TrimSpace(r.Unit) != "" {
+		var ok bool
+		unit, ok = models.ParseUnit(r.Unit)
+		if !ok {
+			return nil, fmt.Errorf("unknown unit %q, use g, ml or pcs", r.Unit)
+		}
+	}
+
+	code := strings.TrimSpace(r.Barcode)
+	if code != "" && !barcode.Valid(code) {
+		return nil, fmt.Errorf("%s is not a valid EAN/UPC barcode", code)
+	}
+
+	return &models.ProductDetails{
+		Name:     name,
+		Ccal:     int64(ccal),
+		Proteins: int64(math.Round(r.Proteins)),
+		Fats:     int64(math.Round(r.Fats)),
+		Carbs:    int64(math.Round(r.Carbs)),
+		Unit:     unit,
+		Brand:    strings.TrimSpace(r.Brand),
+		Barcode:  code,
+	}, nil
+}
+
+// readCSV reads rows by header name along with their line numbers. The
+// delimiter is guessed from the header, since spreadsheets in many locales
+// export with semicolons, and decimal commas are accepted.
+func readCSV(r io.Reader) ([]Row, []int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+
+	header, _, _ := strings.Cut(text, "\n")
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	switch {
+	case strings.Count(header, "\t") > strings.Count(header, ","):
+		reader.Comma = '\t'
+	case strings.Count(header, ";") > strings.Count(header, ","):
+		reader.Comma = ';'
+	}
+
+	columns, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading header: %w", err)
+	}
+	index := make(map[string]int)
+	for i, column := range columns {
+		index[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, required := range []string{"name", "ccal"} {
+		if _, ok := index[required]; !ok {
+			return nil, nil, fmt.Errorf("the header has no %s column, expected %s", required, strings.Join(Columns, ","))
+		}
+	}
+
+	var rows []Row
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, lines, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		get := func(column string) string {
+			if i, ok := index[column]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.Join(record, "") == "" {
+			continue
+		}
+
+		row := Row{Name: get("name"), Unit: get("unit"), Brand: get("brand"), Barcode: get("barcode")}
+		numbers := []struct {
+			column string
+			value  *float64
+		}{{"ccal", &row.Ccal}, {"proteins", &row.Proteins}, {"fats", &row.Fats}, {"carbs", &row.Carbs}}
+		for _, number := range numbers {
+			s := strings.ReplaceAll(get(number.column), ",", ".")
+			if s == "" {
+				continue
+			}
+			*number.value, err = strconv.ParseFloat(s, 64)
+			if err != nil || math.IsNaN(*number.value) || math.IsInf(*number.value, 0) {
+				return nil, nil, fmt.Errorf("line %d: %s is not a number", line, number.column)
+			}
+		}
+
+		rows = append(rows, row)
+		lines = append(lines, line)
+	}
+}
+
+func readJSON(r io.Reader) ([]Row, error) {
+	var rows []Row
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of products with keys %s: %w", strings.Join(Columns, ", "), err)
+	}
+	return rows, nil
+}
+
+// Writer writes products to a file in one of the formats.
+type Writer struct {
+	w     io.Writer
+	csv   *csv.Writer
+	count int
+}
+
+// NewWriter starts a product file in the given format.
+func NewWriter(w io.Writer, format string) (*Writer, error) {
+	writer := &Writer{w: w}
+
+	switch format {
+	case FormatCSV:
+		writer.csv = csv.NewWriter(w)
+		if err := writer.csv.Write(Columns); err != nil {
+			return nil, err
+		}
+	case FormatJSON:
+		if _, err := io.WriteString(w, "["); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	return writer, nil
+}
+
+// Write adds a product to the file.
+func (w *Writer) Write(product *models.ProductDetails) error {
+	w.count++
+
+	if w.csv != nil {
+		return w.csv.Write([]string{
+			product.Name,
+			strconv.FormatInt(product.Ccal, 10),
+			strconv.FormatInt(product.Proteins, 10),
+			strconv.FormatInt(product.Fats, 10),
+			strconv.FormatInt(product.Carbs, 10),
+			product.Unit,
+			product.Brand,
+			product.Barcode,
+		})
+	}
+
+	data, err := json.Marshal(Row{
+		Name:     product.Name,
+		Ccal:     float64(product.Ccal),
+		Proteins: float64(product.Proteins),
+		Fats:     float64(product.Fats),
+		Carbs:    float64(product.Carbs),
+		Unit:     product.Unit,
+		Brand:    product.Brand,
+		Barcode:  product.Barcode,
+	})
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n  "
+	if w.count == 1 {
+		separator = "\n  "
+	}
+	_, err = io.WriteString(w.w, separator+string(data))
+	return err
+}
+
+// Close finishes the file and returns the number of products written.
+func (w *Writer) Close() (int, error) {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.count, w.csv.Error()
+	}
+
+	end := "\n]\n"
+	if w.count == 0 {
+		end = "]\n"
+	}
+	_, err := io.WriteString(w.w, end)
+	return w.count, err
+}
@@ -0,0 +1,121 @@
+package catalog
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"backend/internal/models"
+)
+
+func TestRowProduct(t *testing.T) {
+	valid := Row{Name: " Oat Flakes ", Ccal: 372.4, Proteins: 13.5, Fats: 7, Carbs: 58.7, Unit: "gr", Brand: "Kölln", Barcode: "4006381333931"}
+	product, err := valid.Product()
+	if err != nil {
+		t.Fatalf("Product failed: %v", err)
+	}
+	want := models.ProductDetails{Name: "Oat Flakes", Ccal: 372, Proteins: 14, Fats: 7, Carbs: 59, Unit: models.UnitGram, Brand: "Kölln", Barcode: "4006381333931"}
+	if *product != want {
+		t.Errorf("Product = %+v, want %+v", *product, want)
+	}
+
+	tests := []struct {
+		name string
+		edit func(*Row)
+		want string
+	}{
+		{"no name", func(r *Row) { r.Name = "  " }, "name is required"},
+		{"long name", func(r *Row) { r.Name = strings.Repeat("a", 256) }, "name is longer than 255 characters"},
+		{"long brand", func(r *Row) { r.Brand = strings.Repeat("я", 256) }, "brand is longer than 255 characters"},
+		{"zero ccal", func(r *Row) { r.Ccal = 0 }, "ccal must be positive"},
+		{"ccal rounding to zero", func(r *Row) { r.Ccal = 0.4 }, "ccal must be positive"},
+		{"negative ccal", func(r *Row) { r.Ccal = -5 }, "ccal must be positive"},
+		{"negative fats", func(r *Row) { r.Fats = -1 }, "fats must not be negative"},
+		{"huge ccal", func(r *Row) { r.Ccal = 1e20 }, "ccal must be at most 100000"},
+		{"huge carbs", func(r *Row) { r.Carbs = 100001 }, "carbs must be at most 100000"},
+		{"infinite proteins", func(r *Row) { r.Proteins = math.Inf(1) }, "proteins must be at most 100000"},
+		{"NaN ccal", func(r *Row) { r.Ccal = math.NaN() }, "ccal must be at most 100000"},
+		{"NaN fats", func(r *Row) { r.Fats = math.NaN() }, "fats must be at most 100000"},
+		{"unknown unit", func(r *Row) { r.Unit = "kg" }, `unknown unit "kg", use g, ml or pcs`},
+		{"bad check digit", func(r *Row) { r.Barcode = "4006381333932" }, "4006381333932 is not a valid EAN/UPC barcode"},
+		{"short barcode", func(r *Row) { r.Barcode = "12345" }, "12345 is not a valid EAN/UPC barcode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := valid
+			tt.edit(&row)
+			product, err := row.Product()
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Product = %+v, %v; want error %q", product, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSVDelimiters(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"comma", "name,ccal,proteins,fats,carbs,unit\nOat Flakes,372,13.5,7,58.7,g\n"},
+		// Spreadsheets in many locales use semicolons and decimal commas.
+		{"semicolon", "\ufeffname;ccal;proteins;fats;carbs;unit\r\nOat Flakes;372;13,5;7;58,7;g\r\n"},
+		{"tab", "name\tccal\tproteins\tfats\tcarbs\tunit\nOat Flakes\t372\t13.5\t7\t58.7\tg\n"},
+		{"semicolon with quoted names", "name;ccal;proteins;fats;carbs;unit\n\"Oat Flakes\";372;13,5;7;58,7;g\n"},
+	}
+
+	want := models.ProductDetails{Name: "Oat Flakes", Ccal: 372, Proteins: 14, Fats: 7, Carbs: 59, Unit: models.UnitGram}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, err := Read(strings.NewReader(tt.input), FormatCSV)
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			if len(products) != 1 || *products[0] != want {
+				t.Errorf("Read = %+v, want %+v", products, want)
+			}
+		})
+	}
+}
+
+func TestReadRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   string
+	}{
+		{"no name column", FormatCSV, "product,ccal\nOats,372\n", "the header has no name column, expected name,ccal,proteins,fats,carbs,unit,brand,barcode"},
+		{"not a number", FormatCSV, "name;ccal\nOats;lots\n", "line 2: ccal is not a number"},
+		{"NaN", FormatCSV, "name,ccal\nOats,NaN\n", "line 2: ccal is not a number"},
+		{"invalid rows by line", FormatCSV, "name,ccal,barcode\nOats,372\n\nMilk,-1\nRice,130,123\n",
+			"line 4: ccal must be positive\nline 5: 123 is not a valid EAN/UPC barcode"},
+		{"empty", FormatCSV, "name,ccal\n", "no products in the file"},
+		{"invalid items", FormatJSON, `[{"name": "Oats", "ccal": 372}, {"name": "", "ccal": 50}]`, "item 2: name is required"},
+		{"unknown key", FormatJSON, `[{"name": "Oats", "kcal": 372}]`,
+			`expected a JSON array of products with keys name, ccal, proteins, fats, carbs, unit, brand, barcode: json: unknown field "kcal"`},
+		{"unknown format", "xml", "", `unknown format "xml"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, err := Read(strings.NewReader(tt.input), tt.format)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Read = %+v, %v; want error:\n%s", products, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadTooManyErrors(t *testing.T) {
+	input := "name,ccal\n" + strings.Repeat("Oats,0\n", maxErrors+5)
+
+	_, err := Read(strings.NewReader(input), FormatCSV)
+	if err == nil {
+		t.Fatal("Read accepted invalid rows")
+	}
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != maxErrors+1 || lines[maxErrors] != "..." {
+		t.Errorf("error lists %d lines:\n%v\nwant %d and ...", len(lines), err, maxErrors)
+	}
+}
@@ -0,0 +1,245 @@
+package chart
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"backend/internal/models"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	width       = 900
+	height      = 700
+	marginLeft  = 60
+	marginRight = 20
+	panelGap    = 50
+	gridLines   = 4
+)
+
+var (
+	colorBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	colorAxis       = color.RGBA{0x44, 0x44, 0x44, 0xff}
+	colorGrid       = color.RGBA{0xe0, 0xe0, 0xe0, 0xff}
+	colorText       = color.RGBA{0x22, 0x22, 0x22, 0xff}
+	colorKcal       = color.RGBA{0xe6, 0x55, 0x1f, 0xff}
+	colorGoal       = color.RGBA{0x2e, 0x8b, 0x57, 0xff}
+	colorProteins   = color.RGBA{0x42, 0x85, 0xf4, 0xff}
+	colorFats       = color.RGBA{0xfb, 0xbc, 0x05, 0xff}
+	colorCarbs      = color.RGBA{0x9c, 0x27, 0xb0, 0xff}
+)
+
+// Day is one point of the chart.
+type Day struct {
+	Label     string
+	Nutrition models.Nutrition
+}
+
+// Render draws daily kcal totals against goalKcal (0 for none) above a
+// stacked bar chart of daily proteins, fats and carbs, and encodes it as PNG.
+func Render(days []Day, goalKcal float64) ([]byte, error) {
+	if len(days) == 0 {
+		return nil, fmt.Errorf("no days to chart")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{colorBackground}, image.Point{}, draw.Src)
+
+	panelHeight := (height - 3*panelGap) / 2
+	top := image.Rect(marginLeft, panelGap, width-marginRight, panelGap+panelHeight)
+	bottom := image.Rect(marginLeft, top.Max.Y+panelGap, width-marginRight, top.Max.Y+panelGap+panelHeight)
+
+	drawKcalPanel(img, top, days, goalKcal)
+	drawMacroPanel(img, bottom, days)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func drawKcalPanel(img *image.RGBA, area image.Rectangle, days []Day, goalKcal float64) {
+	maxKcal := goalKcal
+	for _, day := range days {
+		maxKcal = math.Max(maxKcal, day.Nutrition.Kcal)
+	}
+	maxKcal = niceCeil(maxKcal)
+
+	drawText(img, area.Min.X, area.Min.Y-15, "Calories per day, kcal", colorText)
+	drawAxes(img, area, maxKcal)
+	drawLabels(img, area, days)
+
+	y := func(value float64) int {
+		return area.Max.Y - int(value/maxKcal*float64(area.Dy()))
+	}
+
+	if goalKcal > 0 {
+		goalY := y(goalKcal)
+		for x := area.Min.X; x < area.Max.X; x += 12 {
+			drawLine(img, x, goalY, min(x+6, area.Max.X), goalY, colorGoal, 2)
+		}
+		drawText(img, area.Max.X-80, goalY-6, fmt.Sprintf("goal %.0f", goalKcal), colorGoal)
+	}
+
+	prevX, prevY := -1, -1
+	for i, day := range days {
+		x := slotCenter(area, len(days), i)
+		pointY := y(day.Nutrition.Kcal)
+		if prevX >= 0 {
+			drawLine(img, prevX, prevY, x, pointY, colorKcal, 3)
+		}
+		fillRect(img, image.Rect(x-4, pointY-4, x+5, pointY+5), colorKcal)
+		prevX, prevY = x, pointY
+	}
+}
+
+func drawMacroPanel(img *image.RGBA, area image.Rectangle, days []Day) {
+	maxGrams := 0.0
+	for _, day := range days {
+		maxGrams = math.Max(maxGrams, day.Nutrition.Proteins+day.Nutrition.Fats+day.Nutrition.Carbs)
+	}
+	maxGrams = niceCeil(maxGrams)
+
+	drawText(img, area.Min.X, area.Min.Y-15, "Macros per day, g:", colorText)
+	legend := []struct {
+		name string
+		c    color.Color
+	}{{"proteins", colorProteins}, {"fats", colorFats}, {"carbs", colorCarbs}}
+	legendX := area.Min.X + 150
+	for _, item := range legend {
+		fillRect(img, image.Rect(legendX, area.Min.Y-24, legendX+10, area.Min.Y-14), item.c)
+		drawText(img, legendX+14, area.Min.Y-15, item.name, colorText)
+		legendX += 100
+	}
+
+	drawAxes(img, area, maxGrams)
+	drawLabels(img, area, days)
+
+	slot := float64(area.Dx()) / float64(len(days))
+	barWidth := max(int(slot*0.6), 1)
+	for i, day := range days {
+		x := slotCenter(area, len(days), i) - barWidth/2
+		base := area.Max.Y
+		for _, part := range []struct {
+			value float64
+			c     color.Color
+		}{
+			{day.Nutrition.Proteins, colorProteins},
+			{day.Nutrition.Fats, colorFats},
+			{day.Nutrition.Carbs, colorCarbs},
+		} {
+			h := int(part.value / maxGrams * float64(area.Dy()))
+			fillRect(img, image.Rect(x, base-h, x+barWidth, base), part.c)
+			base -= h
+		}
+	}
+}
+
+// drawAxes draws the axes with horizontal grid lines labelled from 0 to maxValue.
+func drawAxes(img *image.RGBA, area image.Rectangle, maxValue float64) {
+	for i := 0; i <= gridLines; i++ {
+		value := maxValue * float64(i) / gridLines
+		y := area.Max.Y - int(float64(area.Dy())*float64(i)/gridLines)
+		if i > 0 {
+			drawLine(img, area.Min.X, y, area.Max.X, y, colorGrid, 1)
+		}
+		drawText(img, area.Min.X-50, y+4, fmt.Sprintf("%6.0f", value), colorText)
+	}
+
+	drawLine(img, area.Min.X, area.Min.Y, area.Min.X, area.Max.Y, colorAxis, 1)
+	drawLine(img, area.Min.X, area.Max.Y, area.Max.X, area.Max.Y, colorAxis, 1)
+}
+
+// drawLabels prints day labels under the x axis, skipping some when there
+// are too many to fit.
+func drawLabels(img *image.RGBA, area image.Rectangle, days []Day) {
+	const labelWidth = 45
+	step := int(math.Ceil(float64(len(days)*labelWidth) / float64(area.Dx())))
+	step = max(step, 1)
+
+	for i := len(days) - 1; i >= 0; i -= step {
+		x := slotCenter(area, len(days), i)
+		drawText(img, x-len(days[i].Label)*7/2, area.Max.Y+16, days[i].Label, colorText)
+	}
+}
+
+func slotCenter(area image.Rectangle, n, i int) int {
+	slot := float64(area.Dx()) / float64(n)
+	return area.Min.X + int(slot*(float64(i)+0.5))
+}
+
+// niceCeil rounds value up to a round number for the axis maximum.
+func niceCeil(value float64) float64 {
+	if value <= 0 {
+		return 1
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(value)))
+	for _, factor := range []float64{1, 2, 2.5, 5, 10} {
+		if factor*magnitude >= value {
+			return factor * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r.Intersect(img.Bounds()), &image.Uniform{c}, image.Point{}, draw.Src)
+}
+
+// drawLine draws a line of the given thickness using Bresenham's algorithm.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color, thickness int) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	half := thickness / 2
+	err := dx + dy
+	for {
+		fillRect(img, image.Rect(x0-half, y0-half, x0-half+thickness, y0-half+thickness), c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func drawText(img *image.RGBA, x, y int, text string, c color.Color) {
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(text)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -1,11 +1,14 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"time"
 
 	"backend/config"
+	"backend/internal/storage"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -13,17 +16,31 @@ import (
 	_ "github.com/lib/pq"
 )
 
+// pingTimeout bounds the first connection, so the bot fails to start instead
+// of hanging when the database is unreachable.
+const pingTimeout = 10 * time.Second
+
 type DB struct {
 	*sql.DB
 }
 
+var _ storage.Store = (*DB)(nil)
+
 func NewConnection(cfg *config.DatabaseConfig) (*DB, error) {
 	db, err := sql.Open("postgres", cfg.GetConnectionString())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
 
-	if err := db.Ping(); err != nil {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
@@ -63,10 +80,10 @@ func (db *DB) RunMigrations(migrationsPath string) error {
 	return nil
 }
 
-func (db *DB) GetLatestSchemaVersion() (string, error) {
+func (db *DB) GetLatestSchemaVersion(ctx context.Context) (string, error) {
 	var version string
 	query := `SELECT version FROM schema_migrations ORDER BY version DESC LIMIT 1`
-	err := db.QueryRow(query).Scan(&version)
+	err := db.QueryRowContext(ctx, query).Scan(&version)
 	if err != nil {
 		return "", err
 	}
@@ -2,31 +2,38 @@ package database
 
 import (
 	"backend/internal/models"
+	"context"
 	"database/sql"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // ProductDetails operations
 
-func (db *DB) InsertProduct(name string, ccal, fats, proteins, carbs int64) (*models.ProductDetails, error) {
+const productColumns = `uuid, name, ccal, fats, proteins, carbs, unit, owner, COALESCE(brand, ''), COALESCE(barcode, '')`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProduct(row rowScanner) (*models.ProductDetails, error) {
 	product := &models.ProductDetails{}
 	
-	query := `
-		INSERT INTO product_details (name, ccal, fats, proteins, carbs)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING uuid, name, ccal, fats, proteins, carbs
-	`
-	
-	err := db.QueryRow(query, name, ccal, fats, proteins, carbs).Scan(
+	err := row.Scan(
 		&product.UUID,
 		&product.Name,
 		&product.Ccal,
 		&product.Fats,
 		&product.Proteins,
 		&product.Carbs,
+		&product.Unit,
+		&product.Owner,
+		&product.Brand,
+		&product.Barcode,
 	)
 	
 	if err != nil {
@@ -36,72 +43,278 @@ func (db *DB) InsertProduct(name string, ccal, fats, proteins, carbs int64) (*mo
 	return product, nil
 }
 
-func (db *DB) GetProductByUUID(productUUID uuid.UUID) (*models.ProductDetails, error) {
-	product := &models.ProductDetails{}
+func scanProducts(rows *sql.Rows) ([]*models.ProductDetails, error) {
+	defer rows.Close()
+	
+	var products []*models.ProductDetails
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	
+	return products, nil
+}
+
+// InsertProduct adds a product to the catalog. A nil owner makes the
+// product shared.
+func (db *DB) InsertProduct(ctx context.Context, name string, ccal, fats, proteins, carbs int64, unit string, owner *int64) (*models.ProductDetails, error) {
+	query := `
+		INSERT INTO product_details (name, ccal, fats, proteins, carbs, unit, owner)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + productColumns
 	
+	return scanProduct(db.QueryRowContext(ctx, query, name, ccal, fats, proteins, carbs, unit, owner))
+}
+
+func (db *DB) GetProductByUUID(ctx context.Context, productUUID uuid.UUID) (*models.ProductDetails, error) {
 	query := `
-		SELECT uuid, name, ccal, fats, proteins, carbs
+		SELECT ` + productColumns + `
 		FROM product_details
 		WHERE uuid = $1
 	`
 	
-	err := db.QueryRow(query, productUUID).Scan(
-		&product.UUID,
-		&product.Name,
-		&product.Ccal,
-		&product.Fats,
-		&product.Proteins,
-		&product.Carbs,
-	)
+	return scanProduct(db.QueryRowContext(ctx, query, productUUID))
+}
+
+// GetProductByName finds a product visible to userID by its exact name,
+// ignoring case. The user's own products take precedence over shared ones.
+func (db *DB) GetProductByName(ctx context.Context, userID int64, name string) (*models.ProductDetails, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM product_details
+		WHERE LOWER(name) = LOWER($2) AND (owner = $1 OR owner IS NULL)
+		ORDER BY owner IS NULL, name
+		LIMIT 1
+	`
 	
+	return scanProduct(db.QueryRowContext(ctx, query, userID, name))
+}
+
+// SearchProductsByName returns products visible to userID that fuzzily match
+// query, best matches first. Substring matches are always included; other
+// names are ranked by trigram word similarity.
+func (db *DB) SearchProductsByName(ctx context.Context, userID int64, query string, limit int) ([]*models.ProductDetails, error) {
+	sqlQuery := `
+		SELECT ` + productColumns + `
+		FROM product_details
+		WHERE (owner = $1 OR owner IS NULL)
+			AND ($2 <% name OR name ILIKE '%' || $3 || '%')
+		ORDER BY LOWER(name) <> LOWER($2),
+			name ILIKE '%' || $3 || '%' DESC,
+			word_similarity($2, name) DESC,
+			owner IS NULL,
+			name
+		LIMIT $4
+	`
+	
+	rows, err := db.QueryContext(ctx, sqlQuery, userID, query, escapeLike(query), limit)
 	if err != nil {
 		return nil, err
 	}
 	
-	return product, nil
+	return scanProducts(rows)
 }
 
-// Record operations
+// GetOrCreateProduct returns the product named name owned by userID,
+// creating it with the given nutrition if the user has none.
+func (db *DB) GetOrCreateProduct(ctx context.Context, userID int64, name string, ccal, fats, proteins, carbs int64, unit string) (*models.ProductDetails, bool, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM product_details
+		WHERE LOWER(name) = LOWER($2) AND owner = $1
+		LIMIT 1
+	`
+	
+	product, err := scanProduct(db.QueryRowContext(ctx, query, userID, name))
+	if err == nil {
+		return product, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, false, err
+	}
+	
+	product, err = db.InsertProduct(ctx, name, ccal, fats, proteins, carbs, unit, &userID)
+	if err != nil {
+		return nil, false, err
+	}
+	
+	return product, true, nil
+}
 
-func (db *DB) InsertRecord(productUUID uuid.UUID, amount int64, login string) (*models.Record, error) {
-	record := &models.Record{}
+// UpdateProduct changes the nutrition of a product owned by userID. Shared
+// products cannot be changed this way and yield sql.ErrNoRows.
+func (db *DB) UpdateProduct(ctx context.Context, userID int64, productUUID uuid.UUID, name string, ccal, fats, proteins, carbs int64, unit string) (*models.ProductDetails, error) {
+	query := `
+		UPDATE product_details
+		SET name = $3, ccal = $4, fats = $5, proteins = $6, carbs = $7, unit = $8
+		WHERE uuid = $1 AND owner = $2
+		RETURNING ` + productColumns
 	
+	return scanProduct(db.QueryRowContext(ctx, query, productUUID, userID, name, ccal, fats, proteins, carbs, unit))
+}
+
+// GetProductByBarcode finds a product visible to userID by any of the given
+// barcode spellings. The user's own products take precedence over shared ones.
+func (db *DB) GetProductByBarcode(ctx context.Context, userID int64, barcodes []string) (*models.ProductDetails, error) {
 	query := `
-		INSERT INTO records (product_uuid, amount, login)
-		VALUES ($1, $2, $3)
-		RETURNING uuid, product_uuid, amount, login, created_at
+		SELECT ` + productColumns + `
+		FROM product_details
+		WHERE barcode = ANY($2) AND (owner = $1 OR owner IS NULL)
+		ORDER BY owner IS NULL, name
+		LIMIT 1
 	`
 	
-	err := db.QueryRow(query, productUUID, amount, login).Scan(
-		&record.UUID,
-		&record.ProductUUID,
-		&record.Amount,
-		&record.Login,
-		&record.CreatedAt,
-	)
+	return scanProduct(db.QueryRowContext(ctx, query, userID, pq.Array(barcodes)))
+}
+
+// UpsertSharedProducts stores products with barcodes in the shared catalog
+// in one transaction, updating the products already known by barcode.
+func (db *DB) UpsertSharedProducts(ctx context.Context, products []*models.ProductDetails) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 	
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO product_details (name, ccal, fats, proteins, carbs, unit, brand, barcode)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+		ON CONFLICT (barcode) WHERE owner IS NULL AND barcode IS NOT NULL
+		DO UPDATE SET name = EXCLUDED.name, ccal = EXCLUDED.ccal, fats = EXCLUDED.fats,
+			proteins = EXCLUDED.proteins, carbs = EXCLUDED.carbs, unit = EXCLUDED.unit, brand = EXCLUDED.brand
+	`)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer stmt.Close()
 	
-	return record, nil
+	for _, p := range products {
+		_, err := stmt.ExecContext(ctx, p.Name, p.Ccal, p.Fats, p.Proteins, p.Carbs, p.Unit, p.Brand, p.Barcode)
+		if err != nil {
+			return fmt.Errorf("product %s: %w", p.Barcode, err)
+		}
+	}
+	
+	return tx.Commit()
 }
 
-func (db *DB) GetRecordByUUID(recordUUID uuid.UUID) (*models.Record, error) {
-	record := &models.Record{}
+// UpsertProducts stores products in the catalog of owner, or the shared
+// catalog for a nil owner, in one transaction. A product replaces the one
+// with the same barcode or, failing that, the same name. Recipe dishes
+// cannot be replaced this way.
+func (db *DB) UpsertProducts(ctx context.Context, owner *int64, products []*models.ProductDetails) (int, int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+	
+	created, updated := 0, 0
+	for _, p := range products {
+		var existing uuid.UUID
+		var isRecipe bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT p.uuid, r.product_uuid IS NOT NULL
+			FROM product_details p
+			LEFT JOIN recipes r ON r.product_uuid = p.uuid
+			WHERE p.owner IS NOT DISTINCT FROM $1
+				AND ((COALESCE(p.barcode, '') = $2 AND $2 <> '') OR LOWER(p.name) = LOWER($3))
+			ORDER BY COALESCE(p.barcode, '') = $2 DESC
+			LIMIT 1
+			FOR UPDATE OF p
+		`, owner, p.Barcode, p.Name).Scan(&existing, &isRecipe)
+		
+		switch {
+		case err == sql.ErrNoRows:
+			_, err = tx.ExecContext(ctx, `
+				INSERT INTO product_details (name, ccal, fats, proteins, carbs, unit, owner, brand, barcode)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''))
+			`, p.Name, p.Ccal, p.Fats, p.Proteins, p.Carbs, p.Unit, owner, p.Brand, p.Barcode)
+			created++
+		case err != nil:
+		case isRecipe:
+			err = fmt.Errorf("%s is a recipe, its nutrition comes from the ingredients", p.Name)
+		default:
+			_, err = tx.ExecContext(ctx, `
+				UPDATE product_details
+				SET name = $2, ccal = $3, fats = $4, proteins = $5, carbs = $6, unit = $7,
+					brand = NULLIF($8, ''), barcode = NULLIF($9, '')
+				WHERE uuid = $1
+			`, existing, p.Name, p.Ccal, p.Fats, p.Proteins, p.Carbs, p.Unit, p.Brand, p.Barcode)
+			updated++
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("product %s: %w", p.Name, err)
+		}
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
 	
+	return created, updated, nil
+}
+
+// EachProduct calls fn with every product in the catalog of owner, or the
+// shared catalog for a nil owner, by name. Products are streamed, so the
+// whole shared catalog can be exported.
+func (db *DB) EachProduct(ctx context.Context, owner *int64, fn func(*models.ProductDetails) error) error {
 	query := `
-		SELECT uuid, product_uuid, amount, login, created_at
-		FROM records
-		WHERE uuid = $1
+		SELECT ` + productColumns + `
+		FROM product_details
+		WHERE owner IS NOT DISTINCT FROM $1
+		ORDER BY LOWER(name), uuid
 	`
 	
-	err := db.QueryRow(query, recordUUID).Scan(
+	rows, err := db.QueryContext(ctx, query, owner)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+	
+	return rows.Err()
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Record operations
+
+const recordColumns = `uuid, product_uuid, amount, user_id, COALESCE(meal, ''), created_at, ccal, fats, proteins, carbs, unit`
+
+func scanRecord(row rowScanner) (*models.Record, error) {
+	record := &models.Record{}
+	
+	err := row.Scan(
 		&record.UUID,
 		&record.ProductUUID,
 		&record.Amount,
-		&record.Login,
+		&record.UserID,
+		&record.Meal,
 		&record.CreatedAt,
+		&record.Ccal,
+		&record.Fats,
+		&record.Proteins,
+		&record.Carbs,
+		&record.Unit,
 	)
 	
 	if err != nil {
@@ -111,15 +324,51 @@ func (db *DB) GetRecordByUUID(recordUUID uuid.UUID) (*models.Record, error) {
 	return record, nil
 }
 
-func (db *DB) GetRecordsByLoginAndTimeRange(login string, startTime, endTime time.Time) ([]*models.Record, error) {
+// InsertRecord stores an eaten amount of a product with its current
+// nutrition. An empty meal is left to be inferred from the record's time.
+func (db *DB) InsertRecord(ctx context.Context, productUUID uuid.UUID, amount float64, userID int64, meal string) (*models.Record, error) {
 	query := `
-		SELECT uuid, product_uuid, amount, login, created_at
+		INSERT INTO records (product_uuid, amount, user_id, meal, ccal, fats, proteins, carbs, unit)
+		SELECT uuid, $2, $3, NULLIF($4, ''), ccal, fats, proteins, carbs, unit
+		FROM product_details
+		WHERE uuid = $1
+		RETURNING ` + recordColumns
+	
+	return scanRecord(db.QueryRowContext(ctx, query, productUUID, amount, userID, meal))
+}
+
+// InsertRecordAt is InsertRecord for a record eaten at createdAt rather
+// than now, e.g. a forgotten meal logged later.
+func (db *DB) InsertRecordAt(ctx context.Context, productUUID uuid.UUID, amount float64, userID int64, meal string, createdAt time.Time) (*models.Record, error) {
+	query := `
+		INSERT INTO records (product_uuid, amount, user_id, meal, created_at, ccal, fats, proteins, carbs, unit)
+		SELECT uuid, $2, $3, NULLIF($4, ''), $5, ccal, fats, proteins, carbs, unit
+		FROM product_details
+		WHERE uuid = $1
+		RETURNING ` + recordColumns
+	
+	return scanRecord(db.QueryRowContext(ctx, query, productUUID, amount, userID, meal, createdAt))
+}
+
+func (db *DB) GetRecordByUUID(ctx context.Context, recordUUID uuid.UUID) (*models.Record, error) {
+	query := `
+		SELECT ` + recordColumns + `
+		FROM records
+		WHERE uuid = $1
+	`
+	
+	return scanRecord(db.QueryRowContext(ctx, query, recordUUID))
+}
+
+func (db *DB) GetRecordsByUserAndTimeRange(ctx context.Context, userID int64, startTime, endTime time.Time) ([]*models.Record, error) {
+	query := `
+		SELECT ` + recordColumns + `
 		FROM records
-		WHERE login = $1 AND created_at >= $2 AND created_at <= $3
+		WHERE user_id = $1 AND created_at >= $2 AND created_at <= $3
 		ORDER BY created_at DESC
 	`
 	
-	rows, err := db.Query(query, login, startTime, endTime)
+	rows, err := db.QueryContext(ctx, query, userID, startTime, endTime)
 	if err != nil {
 		return nil, err
 	}
@@ -127,89 +376,593 @@ func (db *DB) GetRecordsByLoginAndTimeRange(login string, startTime, endTime tim
 	
 	var records []*models.Record
 	for rows.Next() {
-		record := &models.Record{}
+		record, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	
+	return records, nil
+}
+
+// UpdateRecordAmount changes the amount of a record owned by userID. It
+// returns sql.ErrNoRows if the user has no such record.
+func (db *DB) UpdateRecordAmount(ctx context.Context, userID int64, recordUUID uuid.UUID, amount float64) (*models.Record, error) {
+	query := `
+		UPDATE records
+		SET amount = $3
+		WHERE uuid = $1 AND user_id = $2
+		RETURNING ` + recordColumns
+	
+	return scanRecord(db.QueryRowContext(ctx, query, recordUUID, userID, amount))
+}
+
+// DeleteRecord removes a record owned by userID. It returns sql.ErrNoRows if
+// the user has no such record.
+func (db *DB) DeleteRecord(ctx context.Context, userID int64, recordUUID uuid.UUID) error {
+	query := `
+		DELETE FROM records
+		WHERE uuid = $1 AND user_id = $2
+	`
+	
+	result, err := db.ExecContext(ctx, query, recordUUID, userID)
+	if err != nil {
+		return err
+	}
+	
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	
+	return nil
+}
+
+// GetDailyTotals sums the nutrition of the user's records between startTime
+// and endTime per day. Days are calendar days in the given timezone that
+// start at the day-flip time noon; each is identified by its starting date.
+func (db *DB) GetDailyTotals(ctx context.Context, userID int64, startTime, endTime time.Time, timezone string, noon time.Time) ([]*models.DailyTotal, error) {
+	query := `
+		WITH eaten AS (
+			SELECT
+				(DATE_TRUNC('day', (r.created_at AT TIME ZONE $4) - $5::interval))::date AS day,
+				r.amount / CASE WHEN r.unit = 'pcs' THEN 1 ELSE 100 END AS factor,
+				r.ccal, r.proteins, r.fats, r.carbs
+			FROM records r
+			WHERE r.user_id = $1 AND r.created_at >= $2 AND r.created_at < $3
+		)
+		SELECT
+			day,
+			SUM(ccal * factor),
+			SUM(proteins * factor),
+			SUM(fats * factor),
+			SUM(carbs * factor),
+			COUNT(*)
+		FROM eaten
+		GROUP BY day
+		ORDER BY day
+	`
+	
+	rows, err := db.QueryContext(ctx, query, userID, startTime, endTime, timezone, noon.Format("15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var totals []*models.DailyTotal
+	for rows.Next() {
+		total := &models.DailyTotal{}
 		err := rows.Scan(
-			&record.UUID,
-			&record.ProductUUID,
-			&record.Amount,
-			&record.Login,
-			&record.CreatedAt,
+			&total.Day,
+			&total.Nutrition.Kcal,
+			&total.Nutrition.Proteins,
+			&total.Nutrition.Fats,
+			&total.Nutrition.Carbs,
+			&total.Records,
 		)
 		if err != nil {
 			return nil, err
 		}
-		records = append(records, record)
+		totals = append(totals, total)
+	}
+	
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	
+	return totals, nil
+}
+
+// User operations
+
+// EnsureUser records the Telegram user id with their current username, which
+// is empty for users without one. Placeholder users left by the migration
+// from logins keep their username; their data is only handed over by
+// ClaimUser, since a username may have passed to someone else.
+func (db *DB) EnsureUser(ctx context.Context, id int64, username string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	
+	if username != "" {
+		// Usernames are unique, so anyone else who had it has changed theirs
+		_, err = tx.ExecContext(ctx, `
+			UPDATE users
+			SET username = NULL
+			WHERE LOWER(username) = LOWER($1) AND id <> $2 AND id > 0
+		`, username, id)
+		if err != nil {
+			return err
+		}
+	}
+	
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO users (id, username)
+		VALUES ($1, NULLIF($2, ''))
+		ON CONFLICT (id)
+		DO UPDATE SET username = EXCLUDED.username
+	`, id, username)
+	if err != nil {
+		return err
+	}
+	
+	return tx.Commit()
+}
+
+// ClaimUser hands the data of the placeholder users with the given username
+// over to the user id, once an operator has confirmed that the login was
+// theirs. It returns sql.ErrNoRows if there is no such placeholder.
+func (db *DB) ClaimUser(ctx context.Context, username string, id int64) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id
+		FROM users
+		WHERE id < 0 AND LOWER(username) = LOWER($1)
+		FOR UPDATE
+	`, username)
+	if err != nil {
+		return err
+	}
+	
+	var placeholders []int64
+	for rows.Next() {
+		var placeholder int64
+		if err := rows.Scan(&placeholder); err != nil {
+			rows.Close()
+			return err
+		}
+		placeholders = append(placeholders, placeholder)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(placeholders) == 0 {
+		return sql.ErrNoRows
+	}
+	
+	for _, placeholder := range placeholders {
+		if err := claimUser(ctx, tx, placeholder, id); err != nil {
+			return fmt.Errorf("claim user %d: %w", placeholder, err)
+		}
+	}
+	
+	return tx.Commit()
+}
+
+// claimUser hands the data of the placeholder user from over to the user id.
+// If the user already exists, their own settings win and clashing top-level
+// common items of the placeholder get a numeric suffix.
+func claimUser(ctx context.Context, tx *sql.Tx, from, id int64) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		// References to the user follow its primary key
+		_, err := tx.ExecContext(ctx, `UPDATE users SET id = $2 WHERE id = $1`, from, id)
+		return err
+	}
+	
+	rows, err := tx.QueryContext(ctx, `
+		SELECT path
+		FROM user_common_items f
+		WHERE user_id = $1 AND nlevel(path) = 1 AND EXISTS (
+			SELECT 1 FROM user_common_items t WHERE t.user_id = $2 AND t.path = f.path
+		)
+	`, from, id)
+	if err != nil {
+		return err
+	}
+	
+	var clashes []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return err
+		}
+		clashes = append(clashes, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	
+	for _, path := range clashes {
+		newPath, err := freeItemPath(ctx, tx, path, from, id)
+		if err != nil {
+			return err
+		}
+		
+		_, err = tx.ExecContext(ctx, `
+			UPDATE user_common_items
+			SET path = CASE WHEN nlevel(path) = 1 THEN $3::ltree ELSE $3::ltree || subpath(path, 1) END
+			WHERE user_id = $1 AND path <@ $2::ltree
+		`, from, path, newPath)
+		if err != nil {
+			return err
+		}
+	}
+	
+	moves := []string{
+		`UPDATE records SET user_id = $2 WHERE user_id = $1`,
+		`UPDATE product_details SET owner = $2 WHERE owner = $1`,
+		`UPDATE user_common_items SET user_id = $2 WHERE user_id = $1`,
+		`UPDATE user_preferences SET user_id = $2
+			WHERE user_id = $1 AND NOT EXISTS (SELECT 1 FROM user_preferences WHERE user_id = $2)`,
+		`UPDATE user_schedules SET user_id = $2
+			WHERE user_id = $1 AND NOT EXISTS (SELECT 1 FROM user_schedules WHERE user_id = $2)`,
+		`UPDATE sent_notifications s SET user_id = $2
+			WHERE user_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM sent_notifications n WHERE n.user_id = $2 AND n.kind = s.kind AND n.day = s.day
+			)`,
+	}
+	for _, move := range moves {
+		if _, err := tx.ExecContext(ctx, move, from, id); err != nil {
+			return err
+		}
+	}
+	
+	// What is left are the placeholder's settings the user already has
+	deletes := []string{
+		`DELETE FROM user_preferences WHERE user_id = $1`,
+		`DELETE FROM user_schedules WHERE user_id = $1`,
+		`DELETE FROM sent_notifications WHERE user_id = $1`,
+		`DELETE FROM users WHERE id = $1`,
+	}
+	for _, del := range deletes {
+		if _, err := tx.ExecContext(ctx, del, from); err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// GetUserIDByUsername finds a user by their current username, ignoring
+// case. Users who have written to the bot take precedence over placeholders.
+func (db *DB) GetUserIDByUsername(ctx context.Context, username string) (int64, error) {
+	query := `
+		SELECT id
+		FROM users
+		WHERE LOWER(username) = LOWER($1)
+		ORDER BY id DESC
+		LIMIT 1
+	`
+	
+	var id int64
+	err := db.QueryRowContext(ctx, query, username).Scan(&id)
+	return id, err
+}
+
+func (db *DB) GetUsername(ctx context.Context, id int64) (string, error) {
+	var username sql.NullString
+	err := db.QueryRowContext(ctx, `SELECT username FROM users WHERE id = $1`, id).Scan(&username)
+	return username.String, err
+}
+
+// UserPreferences operations
+
+// GetUserPreferencesOrDefault returns the user's preferences, or the column
+// defaults if the user has never set any.
+func (db *DB) GetUserPreferencesOrDefault(ctx context.Context, userID int64) (*models.UserPreferences, error) {
+	prefs, err := db.GetUserPreferences(ctx, userID)
+	if err == sql.ErrNoRows {
+		return models.DefaultUserPreferences(userID), nil
+	}
+	return prefs, err
+}
+
+func (db *DB) GetUserPreferences(ctx context.Context, userID int64) (*models.UserPreferences, error) {
+	prefs := &models.UserPreferences{}
+	
+	query := `
+		SELECT user_id, noon, COALESCE(lang, ''), timezone, goal_kcal, goal_proteins, goal_fats, goal_carbs
+		FROM user_preferences
+		WHERE user_id = $1
+	`
+	
+	err := db.QueryRowContext(ctx, query, userID).Scan(
+		&prefs.UserID,
+		&prefs.Noon,
+		&prefs.Lang,
+		&prefs.Timezone,
+		&prefs.GoalKcal,
+		&prefs.GoalProteins,
+		&prefs.GoalFats,
+		&prefs.GoalCarbs,
+	)
+	
+	if err != nil {
+		return nil, err
+	}
+	
+	return prefs, nil
+}
+
+func (db *DB) UpsertUserNoon(ctx context.Context, userID int64, noon time.Time) error {
+	query := `
+		INSERT INTO user_preferences (user_id, noon)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id)
+		DO UPDATE SET noon = EXCLUDED.noon
+	`
+	
+	_, err := db.ExecContext(ctx, query, userID, noon)
+	return err
+}
+
+func (db *DB) UpsertUserLang(ctx context.Context, userID int64, lang string) error {
+	query := `
+		INSERT INTO user_preferences (user_id, lang)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id)
+		DO UPDATE SET lang = EXCLUDED.lang
+	`
+	
+	_, err := db.ExecContext(ctx, query, userID, lang)
+	return err
+}
+
+func (db *DB) UpsertUserTimezone(ctx context.Context, userID int64, timezone string) error {
+	query := `
+		INSERT INTO user_preferences (user_id, timezone)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id)
+		DO UPDATE SET timezone = EXCLUDED.timezone
+	`
+	
+	_, err := db.ExecContext(ctx, query, userID, timezone)
+	return err
+}
+
+func (db *DB) UpsertUserGoals(ctx context.Context, userID int64, kcal, proteins, fats, carbs int64) error {
+	query := `
+		INSERT INTO user_preferences (user_id, goal_kcal, goal_proteins, goal_fats, goal_carbs)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id)
+		DO UPDATE SET
+			goal_kcal = EXCLUDED.goal_kcal,
+			goal_proteins = EXCLUDED.goal_proteins,
+			goal_fats = EXCLUDED.goal_fats,
+			goal_carbs = EXCLUDED.goal_carbs
+	`
+	
+	_, err := db.ExecContext(ctx, query, userID, kcal, proteins, fats, carbs)
+	return err
+}
+
+// UserSchedule operations
+
+func (db *DB) GetUserSchedule(ctx context.Context, userID int64) (*models.UserSchedule, error) {
+	query := `
+		SELECT user_id, chat_id, daily_summary, reminders
+		FROM user_schedules
+		WHERE user_id = $1
+	`
+	
+	return scanSchedule(db.QueryRowContext(ctx, query, userID))
+}
+
+func (db *DB) GetUserSchedules(ctx context.Context) ([]*models.UserSchedule, error) {
+	query := `
+		SELECT user_id, chat_id, daily_summary, reminders
+		FROM user_schedules
+		WHERE daily_summary OR cardinality(reminders) > 0
+	`
+	
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var schedules []*models.UserSchedule
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	
+	return schedules, nil
+}
+
+func scanSchedule(row rowScanner) (*models.UserSchedule, error) {
+	schedule := &models.UserSchedule{}
+	var reminders []string
+	
+	err := row.Scan(
+		&schedule.UserID,
+		&schedule.ChatID,
+		&schedule.DailySummary,
+		pq.Array(&reminders),
+	)
+	if err != nil {
+		return nil, err
+	}
+	
+	for _, reminder := range reminders {
+		t, err := time.Parse("15:04:05", reminder)
+		if err != nil {
+			return nil, err
+		}
+		schedule.Reminders = append(schedule.Reminders, t)
+	}
+	
+	return schedule, nil
+}
+
+func (db *DB) UpsertUserDailySummary(ctx context.Context, userID int64, chatID int64, enabled bool) error {
+	query := `
+		INSERT INTO user_schedules (user_id, chat_id, daily_summary)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id)
+		DO UPDATE SET chat_id = EXCLUDED.chat_id, daily_summary = EXCLUDED.daily_summary
+	`
+	
+	_, err := db.ExecContext(ctx, query, userID, chatID, enabled)
+	return err
+}
+
+func (db *DB) UpsertUserReminders(ctx context.Context, userID int64, chatID int64, reminders []time.Time) error {
+	times := make([]string, len(reminders))
+	for i, reminder := range reminders {
+		times[i] = reminder.Format("15:04:05")
+	}
+	
+	query := `
+		INSERT INTO user_schedules (user_id, chat_id, reminders)
+		VALUES ($1, $2, $3::time[])
+		ON CONFLICT (user_id)
+		DO UPDATE SET chat_id = EXCLUDED.chat_id, reminders = EXCLUDED.reminders
+	`
+	
+	_, err := db.ExecContext(ctx, query, userID, chatID, pq.Array(times))
+	return err
+}
+
+// ClaimNotification marks a notification of kind for day as sent. It
+// returns false if it has already been claimed, so concurrent or restarted
+// schedulers never send it twice.
+func (db *DB) ClaimNotification(ctx context.Context, userID int64, kind string, day time.Time) (bool, error) {
+	query := `
+		INSERT INTO sent_notifications (user_id, kind, day)
+		VALUES ($1, $2, $3::date)
+		ON CONFLICT DO NOTHING
+	`
+	
+	result, err := db.ExecContext(ctx, query, userID, kind, day.Format("2006-01-02"))
+	if err != nil {
+		return false, err
 	}
 	
-	if err = rows.Err(); err != nil {
-		return nil, err
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
 	}
 	
-	return records, nil
+	return affected == 1, nil
 }
 
-// UserPreferences operations
+// ReleaseNotification undoes ClaimNotification after a failed send so the
+// notification is retried.
+func (db *DB) ReleaseNotification(ctx context.Context, userID int64, kind string, day time.Time) error {
+	query := `
+		DELETE FROM sent_notifications
+		WHERE user_id = $1 AND kind = $2 AND day = $3::date
+	`
+	
+	_, err := db.ExecContext(ctx, query, userID, kind, day.Format("2006-01-02"))
+	return err
+}
 
-func (db *DB) GetUserPreferences(login string) (*models.UserPreferences, error) {
-	prefs := &models.UserPreferences{}
+// UserCommonItem operations
+
+func (db *DB) InsertUserCommonItem(ctx context.Context, userID int64, path, name string, productUUID *uuid.UUID) (*models.UserCommonItem, error) {
+	item := &models.UserCommonItem{}
 	
 	query := `
-		SELECT login, noon, lang
-		FROM user_preferences
-		WHERE login = $1
+		INSERT INTO user_common_items (user_id, path, name, product_uuid)
+		VALUES ($1, $2::ltree, $3, $4)
+		RETURNING uuid, user_id, path, name, product_uuid, created_at
 	`
 	
-	err := db.QueryRow(query, login).Scan(
-		&prefs.Login,
-		&prefs.Noon,
-		&prefs.Lang,
+	err := db.QueryRowContext(ctx, query, userID, path, name, productUUID).Scan(
+		&item.UUID,
+		&item.UserID,
+		&item.Path,
+		&item.Name,
+		&item.ProductUUID,
+		&item.CreatedAt,
 	)
 	
 	if err != nil {
 		return nil, err
 	}
 	
-	return prefs, nil
+	return item, nil
 }
 
-func (db *DB) UpsertUserNoon(login string, noon time.Time) error {
-	query := `
-		INSERT INTO user_preferences (login, noon)
-		VALUES ($1, $2)
-		ON CONFLICT (login)
-		DO UPDATE SET noon = EXCLUDED.noon
-	`
+func (db *DB) GetUserCommonItemByUUID(ctx context.Context, userID int64, itemUUID uuid.UUID) (*models.UserCommonItem, error) {
+	item := &models.UserCommonItem{}
 	
-	_, err := db.Exec(query, login, noon)
-	return err
-}
-
-func (db *DB) UpsertUserLang(login string, lang string) error {
 	query := `
-		INSERT INTO user_preferences (login, lang)
-		VALUES ($1, $2)
-		ON CONFLICT (login)
-		DO UPDATE SET lang = EXCLUDED.lang
+		SELECT uuid, user_id, path, name, product_uuid, created_at
+		FROM user_common_items
+		WHERE user_id = $1 AND uuid = $2
 	`
 	
-	_, err := db.Exec(query, login, lang)
-	return err
+	err := db.QueryRowContext(ctx, query, userID, itemUUID).Scan(
+		&item.UUID,
+		&item.UserID,
+		&item.Path,
+		&item.Name,
+		&item.ProductUUID,
+		&item.CreatedAt,
+	)
+	
+	if err != nil {
+		return nil, err
+	}
+	
+	return item, nil
 }
 
-// UserCommonItem operations
-
-func (db *DB) InsertUserCommonItem(login, path, name string, productUUID *uuid.UUID) (*models.UserCommonItem, error) {
+func (db *DB) GetUserCommonItemByPath(ctx context.Context, userID int64, path string) (*models.UserCommonItem, error) {
 	item := &models.UserCommonItem{}
 	
 	query := `
-		INSERT INTO user_common_items (login, path, name, product_uuid)
-		VALUES ($1, $2::ltree, $3, $4)
-		RETURNING uuid, login, path, name, product_uuid, created_at
+		SELECT uuid, user_id, path, name, product_uuid, created_at
+		FROM user_common_items
+		WHERE user_id = $1 AND path = $2::ltree
 	`
 	
-	err := db.QueryRow(query, login, path, name, productUUID).Scan(
+	err := db.QueryRowContext(ctx, query, userID, path).Scan(
 		&item.UUID,
-		&item.Login,
+		&item.UserID,
 		&item.Path,
 		&item.Name,
 		&item.ProductUUID,
@@ -223,15 +976,15 @@ func (db *DB) InsertUserCommonItem(login, path, name string, productUUID *uuid.U
 	return item, nil
 }
 
-func (db *DB) GetUserCommonItemsByLogin(login string) ([]*models.UserCommonItem, error) {
+func (db *DB) GetUserCommonItemsByUser(ctx context.Context, userID int64) ([]*models.UserCommonItem, error) {
 	query := `
-		SELECT uuid, login, path, name, product_uuid, created_at
+		SELECT uuid, user_id, path, name, product_uuid, created_at
 		FROM user_common_items
-		WHERE login = $1
+		WHERE user_id = $1
 		ORDER BY path
 	`
 	
-	rows, err := db.Query(query, login)
+	rows, err := db.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -242,7 +995,7 @@ func (db *DB) GetUserCommonItemsByLogin(login string) ([]*models.UserCommonItem,
 		item := &models.UserCommonItem{}
 		err := rows.Scan(
 			&item.UUID,
-			&item.Login,
+			&item.UserID,
 			&item.Path,
 			&item.Name,
 			&item.ProductUUID,
@@ -261,15 +1014,15 @@ func (db *DB) GetUserCommonItemsByLogin(login string) ([]*models.UserCommonItem,
 	return items, nil
 }
 
-func (db *DB) GetUserCommonItemsByLoginAndPath(login, pathPattern string) ([]*models.UserCommonItem, error) {
+func (db *DB) GetUserCommonItemsByUserAndPath(ctx context.Context, userID int64, pathPattern string) ([]*models.UserCommonItem, error) {
 	query := `
-		SELECT uuid, login, path, name, product_uuid, created_at
+		SELECT uuid, user_id, path, name, product_uuid, created_at
 		FROM user_common_items
-		WHERE login = $1 AND path ~ $2
+		WHERE user_id = $1 AND path ~ $2
 		ORDER BY path
 	`
 	
-	rows, err := db.Query(query, login, pathPattern)
+	rows, err := db.QueryContext(ctx, query, userID, pathPattern)
 	if err != nil {
 		return nil, err
 	}
@@ -280,7 +1033,7 @@ func (db *DB) GetUserCommonItemsByLoginAndPath(login, pathPattern string) ([]*mo
 		item := &models.UserCommonItem{}
 		err := rows.Scan(
 			&item.UUID,
-			&item.Login,
+			&item.UserID,
 			&item.Path,
 			&item.Name,
 			&item.ProductUUID,
@@ -299,31 +1052,72 @@ func (db *DB) GetUserCommonItemsByLoginAndPath(login, pathPattern string) ([]*mo
 	return items, nil
 }
 
-func (db *DB) GetUserCommonItemsAtLevel(login, parentPath string) ([]*models.UserCommonItem, error) {
+func (db *DB) GetUserCommonItemsAtLevel(ctx context.Context, userID int64, parentPath string) ([]*models.UserCommonItem, error) {
 	var query string
 	var rows *sql.Rows
 	var err error
 	
 	if parentPath == "" {
 		query = `
-			SELECT uuid, login, path, name, product_uuid, created_at
+			SELECT uuid, user_id, path, name, product_uuid, created_at
 			FROM user_common_items
-			WHERE login = $1 AND nlevel(path) = 1
+			WHERE user_id = $1 AND nlevel(path) = 1
 			ORDER BY path
 		`
-		rows, err = db.Query(query, login)
+		rows, err = db.QueryContext(ctx, query, userID)
 	} else {
 		query = `
-			SELECT uuid, login, path, name, product_uuid, created_at
+			SELECT uuid, user_id, path, name, product_uuid, created_at
 			FROM user_common_items
-			WHERE login = $1 AND path ~ $2 AND nlevel(path) = $3
+			WHERE user_id = $1 AND path ~ $2 AND nlevel(path) = $3
 			ORDER BY path
 		`
 		pattern := parentPath + ".*{1}"
 		level := strings.Count(parentPath, ".") + 2
-		rows, err = db.Query(query, login, pattern, level)
+		rows, err = db.QueryContext(ctx, query, userID, pattern, level)
+	}
+	
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var items []*models.UserCommonItem
+	for rows.Next() {
+		item := &models.UserCommonItem{}
+		err := rows.Scan(
+			&item.UUID,
+			&item.UserID,
+			&item.Path,
+			&item.Name,
+			&item.ProductUUID,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
 	}
 	
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	
+	return items, nil
+}
+
+// SearchUserCommonItemsByName returns the user's common items that are
+// linked to a product and whose name contains query.
+func (db *DB) SearchUserCommonItemsByName(ctx context.Context, userID int64, query string, limit int) ([]*models.UserCommonItem, error) {
+	sqlQuery := `
+		SELECT uuid, user_id, path, name, product_uuid, created_at
+		FROM user_common_items
+		WHERE user_id = $1 AND product_uuid IS NOT NULL AND name ILIKE '%' || $2 || '%'
+		ORDER BY name
+		LIMIT $3
+	`
+	
+	rows, err := db.QueryContext(ctx, sqlQuery, userID, escapeLike(query), limit)
 	if err != nil {
 		return nil, err
 	}
@@ -334,7 +1128,7 @@ func (db *DB) GetUserCommonItemsAtLevel(login, parentPath string) ([]*models.Use
 		item := &models.UserCommonItem{}
 		err := rows.Scan(
 			&item.UUID,
-			&item.Login,
+			&item.UserID,
 			&item.Path,
 			&item.Name,
 			&item.ProductUUID,
@@ -352,3 +1146,359 @@ func (db *DB) GetUserCommonItemsAtLevel(login, parentPath string) ([]*models.Use
 	
 	return items, nil
 }
+
+func (db *DB) RenameUserCommonItem(ctx context.Context, userID int64, itemUUID uuid.UUID, name string) (*models.UserCommonItem, error) {
+	item := &models.UserCommonItem{}
+	
+	query := `
+		UPDATE user_common_items
+		SET name = $3
+		WHERE user_id = $1 AND uuid = $2
+		RETURNING uuid, user_id, path, name, product_uuid, created_at
+	`
+	
+	err := db.QueryRowContext(ctx, query, userID, itemUUID, name).Scan(
+		&item.UUID,
+		&item.UserID,
+		&item.Path,
+		&item.Name,
+		&item.ProductUUID,
+		&item.CreatedAt,
+	)
+	
+	if err != nil {
+		return nil, err
+	}
+	
+	return item, nil
+}
+
+// MoveUserCommonItemSubtree moves an item with all its descendants under
+// newParentPath ("" for the root level) and returns the item's new path. The
+// item keeps its label unless it is already taken at the target level.
+func (db *DB) MoveUserCommonItemSubtree(ctx context.Context, userID int64, itemUUID uuid.UUID, newParentPath string) (string, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	
+	var oldPath string
+	err = tx.QueryRowContext(ctx, `
+		SELECT path
+		FROM user_common_items
+		WHERE user_id = $1 AND uuid = $2
+		FOR UPDATE
+	`, userID, itemUUID).Scan(&oldPath)
+	if err != nil {
+		return "", err
+	}
+	
+	if newParentPath == oldPath || strings.HasPrefix(newParentPath, oldPath+".") {
+		return "", fmt.Errorf("cannot move %s into itself", oldPath)
+	}
+	
+	label := oldPath[strings.LastIndex(oldPath, ".")+1:]
+	base := label
+	if newParentPath != "" {
+		base = newParentPath + "." + label
+	}
+	
+	newPath, err := freeItemPath(ctx, tx, base, userID)
+	if err != nil {
+		return "", err
+	}
+	
+	_, err = tx.ExecContext(ctx, `
+		UPDATE user_common_items
+		SET path = $3::ltree || subpath(path, nlevel($2::ltree))
+		WHERE user_id = $1 AND path <@ $2::ltree
+	`, userID, oldPath, newPath)
+	if err != nil {
+		return "", err
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	
+	return newPath, nil
+}
+
+// freeItemPath returns base, or base with a numeric suffix if base is
+// already taken by an item of any of userIDs.
+func freeItemPath(ctx context.Context, tx *sql.Tx, base string, userIDs ...int64) (string, error) {
+	path := base
+	for i := 2; ; i++ {
+		var taken bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM user_common_items WHERE user_id = ANY($1) AND path = $2::ltree
+			)
+		`, pq.Array(userIDs), path).Scan(&taken)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return path, nil
+		}
+		path = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// DeleteUserCommonItemSubtree removes an item with all its descendants and
+// returns the number of deleted items.
+func (db *DB) DeleteUserCommonItemSubtree(ctx context.Context, userID int64, itemUUID uuid.UUID) (int64, error) {
+	query := `
+		DELETE FROM user_common_items
+		WHERE user_id = $1 AND path <@ (
+			SELECT path FROM user_common_items WHERE user_id = $1 AND uuid = $2
+		)
+	`
+	
+	result, err := db.ExecContext(ctx, query, userID, itemUUID)
+	if err != nil {
+		return 0, err
+	}
+	
+	return result.RowsAffected()
+}
+
+// Recipe operations
+
+// CreateRecipe stores a recipe of userID together with its dish product.
+// The dish nutrition must already be computed.
+func (db *DB) CreateRecipe(ctx context.Context, userID int64, recipe *models.Recipe) (*models.Recipe, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	
+	dish := recipe.Product
+	product, err := scanProduct(tx.QueryRowContext(ctx, `
+		INSERT INTO product_details (name, ccal, fats, proteins, carbs, unit, owner)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+productColumns,
+		dish.Name, dish.Ccal, dish.Fats, dish.Proteins, dish.Carbs, dish.Unit, userID))
+	if err != nil {
+		return nil, err
+	}
+	
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO recipes (product_uuid, cooked_weight)
+		VALUES ($1, $2)
+	`, product.UUID, cookedWeight(recipe))
+	if err != nil {
+		return nil, err
+	}
+	
+	if err := insertRecipeIngredients(ctx, tx, product.UUID, recipe.Ingredients); err != nil {
+		return nil, err
+	}
+	
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	
+	return &models.Recipe{
+		Product:      product,
+		CookedWeight: recipe.CookedWeight,
+		Ingredients:  recipe.Ingredients,
+	}, nil
+}
+
+// UpdateRecipe replaces the name, nutrition, cooked weight and ingredients
+// of a recipe owned by userID. Unknown recipes yield sql.ErrNoRows.
+func (db *DB) UpdateRecipe(ctx context.Context, userID int64, recipe *models.Recipe) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	
+	dish := recipe.Product
+	result, err := tx.ExecContext(ctx, `
+		UPDATE product_details p
+		SET name = $3, ccal = $4, fats = $5, proteins = $6, carbs = $7, unit = $8
+		FROM recipes r
+		WHERE p.uuid = $1 AND p.owner = $2 AND r.product_uuid = p.uuid
+	`, dish.UUID, userID, dish.Name, dish.Ccal, dish.Fats, dish.Proteins, dish.Carbs, dish.Unit)
+	if err != nil {
+		return err
+	}
+	
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	
+	_, err = tx.ExecContext(ctx, `UPDATE recipes SET cooked_weight = $2 WHERE product_uuid = $1`, dish.UUID, cookedWeight(recipe))
+	if err != nil {
+		return err
+	}
+	
+	_, err = tx.ExecContext(ctx, `DELETE FROM recipe_ingredients WHERE recipe_uuid = $1`, dish.UUID)
+	if err != nil {
+		return err
+	}
+	
+	if err := insertRecipeIngredients(ctx, tx, dish.UUID, recipe.Ingredients); err != nil {
+		return err
+	}
+	
+	return tx.Commit()
+}
+
+// cookedWeight stores the default weight of a recipe as NULL.
+func cookedWeight(recipe *models.Recipe) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: recipe.CookedWeight, Valid: recipe.CookedWeight > 0}
+}
+
+func insertRecipeIngredients(ctx context.Context, tx *sql.Tx, recipeUUID uuid.UUID, ingredients []models.RecipeIngredient) error {
+	for i, ingredient := range ingredients {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO recipe_ingredients (recipe_uuid, product_uuid, amount, position)
+			VALUES ($1, $2, $3, $4)
+		`, recipeUUID, ingredient.Product.UUID, ingredient.Amount, i)
+		if err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// GetRecipe returns the recipe of userID whose dish is productUUID.
+func (db *DB) GetRecipe(ctx context.Context, userID int64, productUUID uuid.UUID) (*models.Recipe, error) {
+	query := `
+		SELECT ` + productColumns + `, r.cooked_weight
+		FROM product_details p
+		JOIN recipes r ON r.product_uuid = p.uuid
+		WHERE p.uuid = $1 AND p.owner = $2
+	`
+	
+	return db.loadRecipe(ctx, db.QueryRowContext(ctx, query, productUUID, userID))
+}
+
+// GetRecipeByName finds a recipe of userID by its dish name, ignoring case.
+func (db *DB) GetRecipeByName(ctx context.Context, userID int64, name string) (*models.Recipe, error) {
+	query := `
+		SELECT ` + productColumns + `, r.cooked_weight
+		FROM product_details p
+		JOIN recipes r ON r.product_uuid = p.uuid
+		WHERE LOWER(p.name) = LOWER($2) AND p.owner = $1
+		LIMIT 1
+	`
+	
+	return db.loadRecipe(ctx, db.QueryRowContext(ctx, query, userID, name))
+}
+
+func (db *DB) loadRecipe(ctx context.Context, row rowScanner) (*models.Recipe, error) {
+	recipe := &models.Recipe{Product: &models.ProductDetails{}}
+	product := recipe.Product
+	
+	var weight sql.NullFloat64
+	err := row.Scan(
+		&product.UUID,
+		&product.Name,
+		&product.Ccal,
+		&product.Fats,
+		&product.Proteins,
+		&product.Carbs,
+		&product.Unit,
+		&product.Owner,
+		&product.Brand,
+		&product.Barcode,
+		&weight,
+	)
+	if err != nil {
+		return nil, err
+	}
+	recipe.CookedWeight = weight.Float64
+	
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+productColumns+`, i.amount
+		FROM recipe_ingredients i
+		JOIN product_details p ON p.uuid = i.product_uuid
+		WHERE i.recipe_uuid = $1
+		ORDER BY i.position
+	`, product.UUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		ingredient := models.RecipeIngredient{Product: &models.ProductDetails{}}
+		err := rows.Scan(
+			&ingredient.Product.UUID,
+			&ingredient.Product.Name,
+			&ingredient.Product.Ccal,
+			&ingredient.Product.Fats,
+			&ingredient.Product.Proteins,
+			&ingredient.Product.Carbs,
+			&ingredient.Product.Unit,
+			&ingredient.Product.Owner,
+			&ingredient.Product.Brand,
+			&ingredient.Product.Barcode,
+			&ingredient.Amount,
+		)
+		if err != nil {
+			return nil, err
+		}
+		recipe.Ingredients = append(recipe.Ingredients, ingredient)
+	}
+	
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	
+	return recipe, nil
+}
+
+// GetRecipesByUser returns the dishes of all recipes of userID by name.
+func (db *DB) GetRecipesByUser(ctx context.Context, userID int64) ([]*models.ProductDetails, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM product_details p
+		JOIN recipes r ON r.product_uuid = p.uuid
+		WHERE p.owner = $1
+		ORDER BY LOWER(p.name)
+	`
+	
+	rows, err := db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	
+	return scanProducts(rows)
+}
+
+// DeleteRecipe removes a recipe of userID. The dish stays in the catalog as
+// a plain product with its last nutrition, so past records keep working.
+func (db *DB) DeleteRecipe(ctx context.Context, userID int64, productUUID uuid.UUID) error {
+	query := `
+		DELETE FROM recipes r
+		USING product_details p
+		WHERE r.product_uuid = $1 AND p.uuid = r.product_uuid AND p.owner = $2
+	`
+	
+	result, err := db.ExecContext(ctx, query, productUUID, userID)
+	if err != nil {
+		return err
+	}
+	
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	
+	return nil
+}
@@ -0,0 +1,184 @@
+// Package i18n translates bot replies. Messages are written in English in
+// the code and looked up in the catalog of the user's language, so a
+// message without a translation is shown in English.
+package i18n
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"backend/internal/timeout"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+// Supported languages.
+const (
+	English = "en"
+	Russian = "ru"
+)
+
+// Default is the language of users who have not chosen one and whose
+// Telegram client language is not supported.
+const Default = Russian
+
+// Languages lists the supported languages.
+var Languages = []string{English, Russian}
+
+// catalogs map English messages to their translations. Plural messages are
+// keyed by their English plural form.
+var catalogs = map[string]map[string]string{
+	Russian: russian,
+}
+
+var pluralCatalogs = map[string]map[string][]string{
+	Russian: russianPlurals,
+}
+
+// pluralRules return the index of the plural form to use for n.
+var pluralRules = map[string]func(n int) int{
+	English: func(n int) int {
+		if n == 1 {
+			return 0
+		}
+		return 1
+	},
+	// One, few and many: 1 яблоко, 2 яблока, 5 яблок, 21 яблоко, 11 яблок.
+	Russian: func(n int) int {
+		if n < 0 {
+			n = -n
+		}
+		switch {
+		case n%10 == 1 && n%100 != 11:
+			return 0
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return 1
+		default:
+			return 2
+		}
+	},
+}
+
+// missing remembers untranslated messages so each is logged only once.
+var missing sync.Map
+
+// Supported reports whether lang is one of Languages.
+func Supported(lang string) bool {
+	for _, supported := range Languages {
+		if lang == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve picks the language of a user: their stored preference if set,
+// otherwise the language of their Telegram client, otherwise Default.
+func Resolve(stored, telegram string) string {
+	if Supported(stored) {
+		return stored
+	}
+
+	base, _, _ := strings.Cut(strings.ToLower(telegram), "-")
+	if Supported(base) {
+		return base
+	}
+
+	return Default
+}
+
+// Printer formats messages in one language.
+type Printer struct {
+	lang string
+}
+
+// New returns a printer for lang, falling back to English for unsupported
+// languages.
+func New(lang string) *Printer {
+	if !Supported(lang) {
+		lang = English
+	}
+	return &Printer{lang: lang}
+}
+
+// Lang returns the language of the printer.
+func (p *Printer) Lang() string {
+	return p.lang
+}
+
+// T translates a message.
+func (p *Printer) T(message string) string {
+	if p.lang == English {
+		return message
+	}
+
+	if translated, ok := catalogs[p.lang][message]; ok {
+		return translated
+	}
+
+	if _, logged := missing.LoadOrStore(p.lang+"\x00"+message, true); !logged {
+		log.Printf("i18n: no %s translation for %q", p.lang, message)
+	}
+	return message
+}
+
+// Sprintf translates format and formats args with it like fmt.Sprintf.
+func (p *Printer) Sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(p.T(format), args...)
+}
+
+// Plural picks the form of a message for the count n and formats args with
+// it, or n itself without args. one and other are the English forms; one
+// may leave out the count, as in "the last day".
+func (p *Printer) Plural(n int, one, other string, args ...interface{}) string {
+	forms, rule := []string{one, other}, pluralRules[English]
+	if p.lang != English {
+		if translated, ok := pluralCatalogs[p.lang][other]; ok {
+			forms, rule = translated, pluralRules[p.lang]
+		} else if _, logged := missing.LoadOrStore(p.lang+"\x00"+other, true); !logged {
+			log.Printf("i18n: no %s plural translation for %q", p.lang, other)
+		}
+	}
+
+	form := forms[min(rule(n), len(forms)-1)]
+	if len(args) == 0 {
+		if !strings.Contains(form, "%") {
+			return form
+		}
+		args = []interface{}{n}
+	}
+	return fmt.Sprintf(form, args...)
+}
+
+const contextKey = "i18n.printer"
+
+// Middleware stores a printer for the language of the sender of every
+// update, see Resolve. stored returns the language a user has chosen, or
+// an empty string; it gets the context of the update.
+func Middleware(stored func(ctx context.Context, user *tele.User) string) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			if user := c.Sender(); user != nil {
+				c.Set(contextKey, New(Resolve(stored(timeout.From(c), user), user.LanguageCode)))
+			}
+			return next(c)
+		}
+	}
+}
+
+// From returns the printer stored by Middleware, or one for the language
+// of the sender's Telegram client.
+func From(c tele.Context) *Printer {
+	if p, ok := c.Get(contextKey).(*Printer); ok {
+		return p
+	}
+
+	code := ""
+	if user := c.Sender(); user != nil {
+		code = user.LanguageCode
+	}
+	return New(Resolve("", code))
+}
@@ -0,0 +1,358 @@
+package i18n
+
+// russian translates messages to Russian.
+var russian = map[string]string{
+	"\n\n(Empty - click ➕ to add items)":         "\n\n(Пусто - нажмите ➕, чтобы добавить)",
+	"\n\nSimilar products:":                      "\n\nПохожие продукты:",
+	"\n\n🍽 <b>Meals</b>\n":                       "\n\n🍽 <b>Приёмы пищи</b>\n",
+	"\n\n🎯 <b>Goals</b>\n":                       "\n\n🎯 <b>Цели</b>\n",
+	"\n⚡ Energy: ":                               "\n⚡ Энергия: ",
+	"\n📊 Daily average: %s":                      "\n📊 В среднем за день: %s",
+	"\n📋 <b>Total: %s</b>":                       "\n📋 <b>Итого: %s</b>",
+	" (/cancel to stop)":                         " (/cancel - отмена)",
+	" time │    name    │kcal│  P │  F │  C\n":   " время│  название  │ккал│  Б │  Ж │  У\n",
+	" · longest: ":                               " · рекорд: ",
+	"%-9s %5.0f kcal %3.0f%% %s\n":               "%-9s %5.0f ккал %3.0f%% %s\n",
+	"%.0f kcal · P %.0f g · F %.0f g · C %.0f g": "%.0f ккал · Б %.0f г · Ж %.0f г · У %.0f г",
+	"%.0f%s left":                                "осталось %.0f%s",
+	"%.0f%s over":                                "перебор %.0f%s",
+	"%s\n📦 %s\nP %d · F %d · C %d\n\n%s":         "%s\n📦 %s\nБ %d · Ж %d · У %d\n\n%s",
+	"%s is a recipe, its nutrition comes from the ingredients (/recipe edit %s)": "%s - это рецепт, его пищевая ценность считается по ингредиентам (/recipe edit %s)",
+	"%s is made of this recipe, " +
+		"so it cannot be its ingredient": "%s сделан из этого рецепта и не может быть его ингредиентом",
+	"%s is measured in %s, not %s":                        "%s измеряется в %s, а не в %s",
+	"%s is not a valid EAN/UPC barcode, check the digits": "%s - некорректный штрихкод EAN/UPC, проверьте цифры",
+	"%s is not an ingredient of this recipe":              "%s не входит в этот рецепт",
+	"%s · %.0f kcal":                                      "%s · %.0f ккал",
+	"1 or 2pcs":                                           "1 или 2шт",
+	"<b>Summary for %s</b>\n\n":                           "<b>Итоги за %s</b>\n\n",
+	"<b>Today's records:</b>\n\n":                         "<b>Записи за сегодня:</b>\n\n",
+	"A recipe cannot contain itself":                      "Рецепт не может содержать сам себя",
+	"Add at least one ingredient first":                   "Сначала добавьте хотя бы один ингредиент",
+	"Available commands:\n" +
+		"\n" +
+		"/start - Start the bot\n" +
+		"/help - Show this help message\n" +
+		"/ping - Check database connection and schema version\n" +
+		"/get [days] - Get your entries for the last N days (default: 1 day)\n" +
+		"/today - Get today's entries (since your day flip time)\n" +
+		"/stats [week|month|N] - Show averages, best/worst day and streaks\n" +
+		"/chart [days] - Chart of daily calories and macros (default: 14 days)\n" +
+		"/record <name> [amount] [ccal] [proteins] [fats] [carbs] [#meal] [when] - Add a food record (amount: 250g, 300ml, 2pcs; nutrition only the first time; meal: #lunch; when: yesterday 20:30, -2h, @08:15)\n" +
+		"/find <query> - Find a product in the catalog and record it\n" +
+		"Send a barcode number or a photo of a barcode to find a packaged product and record it\n" +
+		"/recipe - Create home-cooked recipes from catalog products and list them\n" +
+		"/import - Add products from a CSV or JSON file\n" +
+		"/export [csv|json] - Download your products as a file\n" +
+		"/edit <id> <amount|kcal> - Change the amount of a record (e.g. 200g or 350kcal)\n" +
+		"/delete <id> - Delete a record\n" +
+		"/cancel - Cancel the current dialog\n" +
+		"/notify - Daily summary and meal reminder settings\n" +
+		"/set_noon <HH:MM> - Set your day flip time (default: 00:00)\n" +
+		"/set_goal <kcal> [proteins] [fats] [carbs] - Set your daily goals (/set_goal off to clear)\n" +
+		"/set_lang <lang> - Set your language (ru/en)\n" +
+		"/set_tz <timezone> - Set your timezone (e.g. Europe/Berlin)": "Доступные команды:\n" +
+		"\n" +
+		"/start - Запустить бота\n" +
+		"/help - Показать эту справку\n" +
+		"/ping - Проверить подключение к базе данных и версию схемы\n" +
+		"/get [дни] - Записи за последние N дней (по умолчанию 1 день)\n" +
+		"/today - Записи за сегодня (с начала ваших суток)\n" +
+		"/stats [week|month|N] - Средние значения, лучший и худший день, серии\n" +
+		"/chart [дни] - График калорий и БЖУ по дням (по умолчанию 14 дней)\n" +
+		"/record <название> [количество] [ккал] [белки] [жиры] [углеводы] [#приём] [когда] - Добавить запись (количество: 250g, 300ml, 2pcs; пищевая ценность нужна только в первый раз; приём: #lunch; когда: yesterday 20:30, -2h, @08:15)\n" +
+		"/find <запрос> - Найти продукт в каталоге и записать его\n" +
+		"Отправьте цифры штрихкода или его фото, чтобы найти упакованный продукт и записать его\n" +
+		"/recipe - Создать рецепты домашних блюд из продуктов каталога и посмотреть их\n" +
+		"/import - Добавить продукты из файла CSV или JSON\n" +
+		"/export [csv|json] - Скачать ваши продукты файлом\n" +
+		"/edit <id> <количество|kcal> - Изменить количество в записи (например, 200g или 350kcal)\n" +
+		"/delete <id> - Удалить запись\n" +
+		"/cancel - Отменить текущий диалог\n" +
+		"/notify - Ежедневные итоги и напоминания о приёмах пищи\n" +
+		"/set_noon <ЧЧ:ММ> - Время начала суток (по умолчанию 00:00)\n" +
+		"/set_goal <ккал> [белки] [жиры] [углеводы] - Дневные цели (/set_goal off - сбросить)\n" +
+		"/set_lang <язык> - Язык бота (ru/en)\n" +
+		"/set_tz <часовой пояс> - Часовой пояс (например, Europe/Moscow)",
+	"Calories are too low for this product": "Слишком мало калорий для этого продукта",
+	"Calories must be a positive number":    "Калории должны быть положительным числом",
+	"Cancel":                                "Отмена",
+	"Carbs must be a non-negative number":   "Углеводы должны быть неотрицательным числом",
+	"Cooked weight: %s\n":                   "Вес готового блюда: %s\n",
+	"Error adding item":                     "Ошибка при добавлении",
+	"Error creating record":                 "Ошибка при создании записи",
+	"Error deleting item":                   "Ошибка при удалении",
+	"Error deleting record":                 "Ошибка при удалении записи",
+	"Error loading items":                   "Ошибка при загрузке",
+	"Error moving item":                     "Ошибка при перемещении",
+	"Fats must be a non-negative number":    "Жиры должны быть неотрицательным числом",
+	"Goals must be non-negative numbers":    "Цели должны быть неотрицательными числами",
+	"How much %s did you eat? Send an amount in %s, e.g. 150%s or 150%s -2h for earlier (/cancel to stop)": "Сколько %s вы съели? Отправьте количество в %s, например 150%s, или 150%s -2h, если раньше (/cancel - отмена)",
+	"How much %s did you eat? Send an amount like %s, optionally with a time like -2h (/cancel to stop)":   "Сколько %s вы съели? Отправьте количество, например %s, можно со временем, например -2h (/cancel - отмена)",
+	"Invalid action": "Неизвестное действие",
+	"Invalid amount": "Некорректное количество",
+	"Invalid amount, send e.g. 150%s (/cancel to stop)":                         "Некорректное количество, отправьте, например, 150%s (/cancel - отмена)",
+	"Invalid amount. Use a positive number with an optional unit: g, ml or pcs": "Некорректное количество. Укажите положительное число и, если нужно, единицу: г, мл или шт",
+	"Invalid amount. Use e.g. 200g, 2pcs or 350kcal":                            "Некорректное количество. Например: 200g, 2pcs или 350kcal",
+	"Invalid item":      "Некорректный элемент",
+	"Invalid number %q": "Некорректное число %q",
+	"Invalid product":   "Некорректный продукт",
+	"Invalid record":    "Некорректная запись",
+	"Invalid record ID": "Некорректный ID записи",
+	"Invalid time %q. Use HH:MM (e.g., 13:00)":     "Некорректное время %q. Используйте ЧЧ:ММ (например, 13:00)",
+	"Invalid time format. Use HH:MM (e.g., 03:00)": "Некорректный формат времени. Используйте ЧЧ:ММ (например, 03:00)",
+	"Invalid time. Use @HH:MM, e.g. @08:15":        "Некорректное время. Используйте @ЧЧ:ММ, например @08:15",
+	"Item not found":                               "Элемент не найден",
+	"Items can only be moved into folders":         "Перемещать можно только в папки",
+	"Main Menu:":                                   "Главное меню:",
+	"No records for this day.":                     "За этот день записей нет.",
+	"Nothing found for \"%s\"":                     "По запросу \"%s\" ничего не найдено",
+	"Nothing found for \"%s\".\n\n":                "По запросу \"%s\" ничего не найдено.\n\n",
+	"Nothing to add, start again with ➕":           "Нечего добавлять, начните заново с ➕",
+	"Nothing to cancel":                            "Нечего отменять",
+	"Nothing to move":                              "Нечего перемещать",
+	"Only one time qualifier is allowed":           "Можно указать только одно время",
+	"P %.0f%% · F %.0f%% · C %.0f%%":               "Б %.0f%% · Ж %.0f%% · У %.0f%%",
+	"Per 100 g: %d kcal · P %d · F %d · C %d":      "На 100 г: %d ккал · Б %d · Ж %d · У %d",
+	"Pick one from the catalog, send another name to search, or send the nutrition of a new product: <ccal> [proteins] [fats] [carbs] [g|ml|pcs]\n" +
+		"Nutrition is per 100 g/ml, or per piece by default.": "Выберите продукт из каталога, отправьте другое название для поиска или пищевую ценность нового продукта: <ккал> [белки] [жиры] [углеводы] [g|ml|pcs]\n" +
+		"Пищевая ценность указывается на 100 г/мл, по умолчанию - на штуку.",
+	"Product not found":                      "Продукт не найден",
+	"Proteins must be a non-negative number": "Белки должны быть неотрицательным числом",
+	"Record deleted":                         "Запись удалена",
+	"Record it once with its nutrition: /record %s 100g <ccal> [proteins] [fats] [carbs]": "Запишите его один раз с пищевой ценностью: /record %s 100g <ккал> [белки] [жиры] [углеводы]",
+	"Record not found": "Запись не найдена",
+	"Recorded":         "Записано",
+	"Send a .csv or .json file with /import as its caption to add or update products in your catalog.\n" +
+		"Columns: %s\n" +
+		"Only name and ccal are required. Nutrition is per 100 g/ml or per piece, the unit (g, ml or pcs) defaults to g.\n" +
+		"Products with the same barcode or name are updated. Get the current list with /export": "Отправьте файл .csv или .json с подписью /import, чтобы добавить или обновить продукты в вашем каталоге.\n" +
+		"Столбцы: %s\n" +
+		"Обязательны только name и ccal. Пищевая ценность указывается на 100 г/мл или на штуку, единица (g, ml или pcs) по умолчанию g.\n" +
+		"Продукты с тем же штрихкодом или названием обновляются. Текущий список можно получить командой /export",
+	"Send ingredients one per message as <product> <amount>, e.g. rice 200g.\n" +
+		"remove <product> - Remove an ingredient\n" +
+		"done [cooked weight] - Save, e.g. done 850g (defaults to the weight of the ingredients)\n" +
+		"/cancel - Stop without saving": "Отправляйте ингредиенты по одному в сообщении: <продукт> <количество>, например рис 200g.\n" +
+		"remove <продукт> - Убрать ингредиент\n" +
+		"done [вес готового блюда] - Сохранить, например done 850g (по умолчанию - вес ингредиентов)\n" +
+		"/cancel - Выйти без сохранения",
+	"Send the cooked weight of the dish, e.g. done 850g":                             "Отправьте вес готового блюда, например done 850g",
+	"Send the new amount (e.g. 200g) or calories (e.g. 350kcal), /cancel to keep it": "Отправьте новое количество (например, 200g) или калории (например, 350kcal), /cancel - оставить как есть",
+	"The cooked weight must be in grams, e.g. done 850g":                             "Вес готового блюда указывается в граммах, например done 850g",
+	"The file is too large, the limit is 20 MB":                                      "Файл слишком большой, ограничение - 20 МБ",
+	"The name cannot be empty":                                                       "Название не может быть пустым",
+	"The time can be at most 366 days ago":                                           "Время может быть не раньше, чем 366 дней назад",
+	"The time cannot be in the future":                                               "Время не может быть в будущем",
+	"Too many values. Usage: /set_goal <kcal> [proteins] [fats] [carbs]":             "Слишком много значений. Использование: /set_goal <ккал> [белки] [жиры] [углеводы]",
+	"Too many values. Use: <ccal> [proteins] [fats] [carbs] [g|ml|pcs]":              "Слишком много значений. Формат: <ккал> [белки] [жиры] [углеводы] [g|ml|pcs]",
+	"Total: %.0f kcal · P %.0f · F %.0f · C %.0f · %s raw":                           "Итого: %.0f ккал · Б %.0f · Ж %.0f · У %.0f · %s сырого веса",
+	"Unknown action":           "Неизвестное действие",
+	"Unknown meal %s. Use #%s": "Неизвестный приём пищи %s. Используйте #%s",
+	"Unknown timezone. Use an IANA name such as Europe/Moscow or America/New_York": "Неизвестный часовой пояс. Используйте название IANA, например Europe/Moscow или Asia/Yekaterinburg",
+	"Unknown unit. Use g, ml or pcs":                                               "Неизвестная единица. Используйте г, мл или шт",
+	"Unsupported language. Available: %s":                                          "Язык не поддерживается. Доступны: %s",
+	"Usage:\n" +
+		"/notify - Show your notification settings\n" +
+		"/notify summary on|off - Daily summary of the previous day at your day flip time\n" +
+		"/notify remind <HH:MM> [HH:MM...] - Remind to log meals at these times if nothing was logged\n" +
+		"/notify remind off - Disable reminders": "Использование:\n" +
+		"/notify - Показать настройки уведомлений\n" +
+		"/notify summary on|off - Итоги предыдущего дня в момент начала новых суток\n" +
+		"/notify remind <ЧЧ:ММ> [ЧЧ:ММ...] - Напоминать о записи еды в это время, если ничего не записано\n" +
+		"/notify remind off - Отключить напоминания",
+	"Usage:\n" +
+		"/recipe - List your recipes\n" +
+		"/recipe new <name> - Create a recipe from catalog products\n" +
+		"/recipe show <name> - Show ingredients and nutrition per 100 g\n" +
+		"/recipe edit <name> - Change ingredients or cooked weight\n" +
+		"/recipe delete <name> - Delete a recipe (the dish stays in your catalog)\n" +
+		"Log a portion like any product: /record <name> 300g": "Использование:\n" +
+		"/recipe - Список ваших рецептов\n" +
+		"/recipe new <название> - Создать рецепт из продуктов каталога\n" +
+		"/recipe show <название> - Ингредиенты и пищевая ценность на 100 г\n" +
+		"/recipe edit <название> - Изменить ингредиенты или вес готового блюда\n" +
+		"/recipe delete <название> - Удалить рецепт (блюдо останется в каталоге)\n" +
+		"Порция записывается как любой продукт: /record <название> 300g",
+	"Usage: /chart [days]\nDays must be between 1 and %d": "Использование: /chart [дни]\nЧисло дней - от 1 до %d",
+	"Usage: /delete <id>": "Использование: /delete <id>",
+	"Usage: /edit <id> <amount|kcal>\nExample: /edit <id> 200g or /edit <id> 350kcal": "Использование: /edit <id> <количество|kcal>\nПример: /edit <id> 200g или /edit <id> 350kcal",
+	"Usage: /export [csv|json]":                         "Использование: /export [csv|json]",
+	"Usage: /find <query>\nExample: /find chicken":      "Использование: /find <запрос>\nПример: /find курица",
+	"Usage: /get [days]\nDays must be between 1 and %d": "Использование: /get [дни]\nЧисло дней - от 1 до %d",
+	"Usage: /record <name> [amount] [ccal] [proteins] [fats] [carbs] [#meal] [when]\n" +
+		"Amount is like 250g, 300ml or 2pcs. Nutrition is per 100 g/ml, or per piece when no amount is given. Nutrition is only needed the first time you record a product. The meal (#breakfast, #lunch, #dinner, #snack) is guessed from the time unless given. When is now unless given as yesterday 20:30, -2h or @08:15.\n" +
+		"Example: /record Chicken Breast 250g 165 31 3 0": "Использование: /record <название> [количество] [ккал] [белки] [жиры] [углеводы] [#приём] [когда]\n" +
+		"Количество указывается как 250g, 300ml или 2pcs. Пищевая ценность - на 100 г/мл или на штуку, если количество не указано. Она нужна только при первой записи продукта. Приём пищи (#breakfast, #lunch, #dinner, #snack) определяется по времени, если не указан. Время - сейчас, если не указано как yesterday 20:30, -2h или @08:15.\n" +
+		"Пример: /record Куриная грудка 250g 165 31 3 0",
+	"Usage: /set_goal <kcal> [proteins] [fats] [carbs]\n" +
+		"Example: /set_goal 2000 120 70 220\n" +
+		"Use /set_goal off to clear your goals": "Использование: /set_goal <ккал> [белки] [жиры] [углеводы]\n" +
+		"Пример: /set_goal 2000 120 70 220\n" +
+		"/set_goal off - сбросить цели",
+	"Usage: /set_lang <lang>\nExample: /set_lang ru\nSupported: %s": "Использование: /set_lang <язык>\nПример: /set_lang en\nДоступны: %s",
+	"Usage: /set_noon <HH:MM>\nExample: /set_noon 03:00":            "Использование: /set_noon <ЧЧ:ММ>\nПример: /set_noon 03:00",
+	"Usage: /set_tz <timezone>\nExample: /set_tz Europe/Berlin\nUse IANA timezone names": "Использование: /set_tz <часовой пояс>\n" +
+		"Пример: /set_tz Europe/Moscow\n" +
+		"Используйте названия часовых поясов IANA",
+	"Usage: /stats [week|month|N]\nN is a number of days from 1 to %d\n" +
+		"Example: /stats month or /stats 14": "Использование: /stats [week|month|N]\nN - число дней от 1 до %d\n" +
+		"Пример: /stats month или /stats 14",
+	"Usage: done [cooked weight], e.g. done 850g":                   "Использование: done [вес готового блюда], например done 850g",
+	"Welcome to C-Meter! 👋\n\nUse /help to see available commands.": "Добро пожаловать в C-Meter! 👋\n\nСписок команд - /help.",
+	"What do you want to add?":                                      "Что вы хотите добавить?",
+	"Which product is \"%s\"?\n\n":                                  "Какой продукт - \"%s\"?\n\n",
+	"You already have a product named %s. Pick another name":        "У вас уже есть продукт %s. Выберите другое название",
+	"You have no recipe named %s":                                   "У вас нет рецепта %s",
+	"You have no recipes yet.\n\n":                                  "У вас пока нет рецептов.\n\n",
+	"Your catalog is empty.\n\n":                                    "Ваш каталог пуст.\n\n",
+	"breakfast":                                                     "завтрак",
+	"carbs":                                                         "углеводы",
+	"dinner":                                                        "ужин",
+	"fat":                                                           "жиры",
+	"g":                                                             "г",
+	"kcal":                                                          "ккал",
+	"lunch":                                                         "обед",
+	"ml":                                                            "мл",
+	"off":                                                           "выкл",
+	"on":                                                            "вкл",
+	"pc":                                                            "шт",
+	"pcs":                                                           "шт",
+	"protein":                                                       "белки",
+	"snack":                                                         "перекус",
+	"• %s - %d kcal per 100 g\n":                                    "• %s - %d ккал на 100 г\n",
+	"• %s - %s (%.0f kcal)\n":                                       "• %s - %s (%.0f ккал)\n",
+	"ℹ️ Your catalog already has %s with %d kcal per %s, the item uses it. Change its nutrition with /record.": "ℹ️ В вашем каталоге уже есть %s, %d ккал на %s, элемент использует его. Изменить пищевую ценность можно через /record.",
+	"⏰ It's %s and nothing is logged since %s. Don't forget to /record your meal!":                             "⏰ Уже %s, а с %s ничего не записано. Не забудьте записать еду: /record",
+	"⚙️ Manage": "⚙️ Управление",
+	"⚙️ Manage items\n\n✏️ rename · 📦 move · 🗑 delete": "⚙️ Управление\n\n✏️ переименовать · 📦 переместить · 🗑 удалить",
+	"⚡ Energy: ":            "⚡ Энергия: ",
+	"✅ Daily goals cleared": "✅ Дневные цели сброшены",
+	"✅ Daily goals set: %d kcal, proteins %d g, fats %d g, carbs %d g":   "✅ Дневные цели: %d ккал, белки %d г, жиры %d г, углеводы %d г",
+	"✅ Daily summary disabled":                                           "✅ Ежедневные итоги отключены",
+	"✅ Database connected\n📦 No migrations applied yet":                  "✅ База данных подключена\n📦 Миграции ещё не применялись",
+	"✅ Database connected\n📦 Schema version: %s":                         "✅ База данных подключена\n📦 Версия схемы: %s",
+	"✅ Day flip time set to %s":                                          "✅ Сутки начинаются в %s",
+	"✅ Done":                                                             "✅ Готово",
+	"✅ Language set to %s":                                               "✅ Язык: %s",
+	"✅ Recipe saved\n\n%s\n\nLog a portion: /record %s 300g":             "✅ Рецепт сохранён\n\n%s\n\nЗаписать порцию: /record %s 300g",
+	"✅ Recorded: %s (%s)\n📊 Calories: %.0f":                              "✅ Записано: %s (%s)\n📊 Калории: %.0f",
+	"✅ Recorded: %s (%s)\n📊 Calories: %.0f\n🍽 Meal: %s · 🕒 %s\nID: %s":   "✅ Записано: %s (%s)\n📊 Калории: %.0f\n🍽 Приём пищи: %s · 🕒 %s\nID: %s",
+	"✅ Reminders disabled":                                               "✅ Напоминания отключены",
+	"✅ Reminders set for %s":                                             "✅ Напоминания в %s",
+	"✅ Timezone set to %s\n🕒 Local time: %s":                             "✅ Часовой пояс: %s\n🕒 Местное время: %s",
+	"✅ You will get a summary of the previous day at your day flip time": "✅ Итоги предыдущего дня будут приходить в момент начала новых суток",
+	"✍️ Custom amount":                                                   "✍️ Своё количество",
+	"✏️ Edit":                                                            "✏️ Изменить",
+	"✏️ Send a new name for \"%s\" (/cancel to stop)":                    "✏️ Отправьте новое название для \"%s\" (/cancel - отмена)",
+	"✏️ Updated: %s (%s)\n📊 Calories: %.0f":                              "✏️ Изменено: %s (%s)\n📊 Калории: %.0f",
+	"❌ Cancelled":                                                        "❌ Отменено",
+	"❌ Database error: %v":                                               "❌ Ошибка базы данных: %v",
+	"❌ Error adding folder: %v":                                          "❌ Ошибка при добавлении папки: %v",
+	"❌ Error adding item: %v":                                            "❌ Ошибка при добавлении: %v",
+	"❌ Error creating product: %v":                                       "❌ Ошибка при создании продукта: %v",
+	"❌ Error creating record: %v":                                        "❌ Ошибка при создании записи: %v",
+	"❌ Error deleting recipe: %v":                                        "❌ Ошибка при удалении рецепта: %v",
+	"❌ Error deleting record: %v":                                        "❌ Ошибка при удалении записи: %v",
+	"❌ Error downloading file: %v":                                       "❌ Ошибка при загрузке файла: %v",
+	"❌ Error downloading photo: %v":                                      "❌ Ошибка при загрузке фото: %v",
+	"❌ Error exporting products: %v":                                     "❌ Ошибка при выгрузке продуктов: %v",
+	"❌ Error fetching records: %v":                                       "❌ Ошибка при получении записей: %v",
+	"❌ Error fetching statistics: %v":                                    "❌ Ошибка при получении статистики: %v",
+	"❌ Error finding product: %v":                                        "❌ Ошибка при поиске продукта: %v",
+	"❌ Error getting recipe: %v":                                         "❌ Ошибка при получении рецепта: %v",
+	"❌ Error getting recipes: %v":                                        "❌ Ошибка при получении рецептов: %v",
+	"❌ Error importing products: %v":                                     "❌ Ошибка при импорте продуктов: %v",
+	"❌ Error loading notification settings: %v":                          "❌ Ошибка при загрузке настроек уведомлений: %v",
+	"❌ Error loading product: %v":                                        "❌ Ошибка при загрузке продукта: %v",
+	"❌ Error reading barcode: %v":                                        "❌ Ошибка при чтении штрихкода: %v",
+	"❌ Error reading photo: %v":                                          "❌ Ошибка при чтении фото: %v",
+	"❌ Error renaming item: %v":                                          "❌ Ошибка при переименовании: %v",
+	"❌ Error rendering chart: %v":                                        "❌ Ошибка при построении графика: %v",
+	"❌ Error saving notification settings: %v":                           "❌ Ошибка при сохранении настроек уведомлений: %v",
+	"❌ Error saving recipe: %v":                                          "❌ Ошибка при сохранении рецепта: %v",
+	"❌ Error searching products: %v":                                     "❌ Ошибка при поиске продуктов: %v",
+	"❌ Error setting day flip time: %v":                                  "❌ Ошибка при установке начала суток: %v",
+	"❌ Error setting goals: %v":                                          "❌ Ошибка при установке целей: %v",
+	"❌ Error setting language: %v":                                       "❌ Ошибка при установке языка: %v",
+	"❌ Error setting timezone: %v":                                       "❌ Ошибка при установке часового пояса: %v",
+	"❌ Error updating record: %v":                                        "❌ Ошибка при изменении записи: %v",
+	"❌ The file was not imported:\n%v":                                   "❌ Файл не импортирован:\n%v",
+	"➕ %s (%s)\n%s":                                                      "➕ %s (%s)\n%s",
+	"➕ Add item":                                                         "➕ Добавить",
+	"➖ %s removed\n%s":                                                   "➖ %s убран\n%s",
+	"⬅️ Back":                                                            "⬅️ Назад",
+	"🍲 New recipe %s\n%s":                                                "🍲 Новый рецепт %s\n%s",
+	"🍲 Your recipes:\n":                                                  "🍲 Ваши рецепты:\n",
+	"🍽️ %s\n×1 = %s · %.0f kcal\n\nHow much did you eat?":                "🍽️ %s\n×1 = %s · %.0f ккал\n\nСколько вы съели?",
+	"🍽️ %s · %d kcal":                                                    "🍽️ %s · %d ккал",
+	"🍽️ %s · %d kcal/%s":                                                 "🍽️ %s · %d ккал/%s",
+	"🍽️ %s — %s · %.0f kcal":                                             "🍽️ %s — %s · %.0f ккал",
+	"🍽️ Food item":                                                       "🍽️ Блюдо",
+	"🍽️ Send the food item name (/cancel to stop)":                       "🍽️ Отправьте название блюда (/cancel - отмена)",
+	"🏆 Best day: %s · %.0f kcal\n":                                       "🏆 Лучший день: %s · %.0f ккал\n",
+	"📁 Folder":                                                           "📁 Папка",
+	"📁 Send the folder name (/cancel to stop)":                           "📁 Отправьте название папки (/cancel - отмена)",
+	"📆 Logged days: %d of %d\n":                                          "📆 Дней с записями: %d из %d\n",
+	"📉 Worst day: %s · %.0f kcal\n":                                      "📉 Худший день: %s · %.0f ккал\n",
+	"📍 Locations":                                                        "📍 Места",
+	"📍 Top level":                                                        "📍 Верхний уровень",
+	"📦 Move":                                                             "📦 Переместить",
+	"📦 Move \"%s\" to:":                                                  "📦 Переместить \"%s\" в:",
+	"🔎 Results for \"%s\":":                                              "🔎 Результаты по запросу \"%s\":",
+	"🔔 Daily summary: %s\n⏰ Reminders: %s\n\n%s":                         "🔔 Ежедневные итоги: %s\n⏰ Напоминания: %s\n\n%s",
+	"🔕 No notifications set up\n\n":                                      "🔕 Уведомления не настроены\n\n",
+	"🔗 Current streak: ":                                                 "🔗 Текущая серия: ",
+	"🔥 Daily average: %.0f kcal\n":                                       "🔥 В среднем за день: %.0f ккал\n",
+	"🗑 Delete":                                                           "🗑 Удалить",
+	"🗑 Delete \"%s\"?":                                                   "🗑 Удалить \"%s\"?",
+	"🗑 Delete folder \"%s\" with everything inside?":                     "🗑 Удалить папку \"%s\" со всем содержимым?",
+	"🗑 Recipe %s deleted. The dish stays in your catalog with its last nutrition": "🗑 Рецепт %s удалён. Блюдо остаётся в каталоге с последней пищевой ценностью",
+	"🗑 Record deleted":                                               "🗑 Запись удалена",
+	"🤷 \"%s\" is not in your catalog yet.\n":                         "🤷 \"%s\" пока нет в вашем каталоге.\n",
+	"🤷 \"%s\" is not in your catalog yet. Add it with /record first": "🤷 \"%s\" пока нет в вашем каталоге. Сначала добавьте его через /record",
+	"🤷 Couldn't read a barcode. Take the photo closer with the barcode level and in focus, or send its digits": "🤷 Не удалось прочитать штрихкод. Сфотографируйте ближе, ровно и в фокусе или отправьте его цифры",
+	"🤷 No product with barcode %s in the catalog.\n" +
+		"Record it once with its nutrition: /record <name> 100g <ccal> [proteins] [fats] [carbs]": "🤷 Продукта со штрихкодом %s нет в каталоге.\n" +
+		"Запишите его один раз с пищевой ценностью: /record <название> 100g <ккал> [белки] [жиры] [углеводы]",
+	"🥩 Average macros: P %.0f g · F %.0f g · C %.0f g\n": "🥩 БЖУ в среднем: Б %.0f г · Ж %.0f г · У %.0f г\n",
+}
+
+// russianPlurals holds the one, few and many forms of plural messages.
+var russianPlurals = map[string][]string{
+	"%d days": {
+		"%d день",
+		"%d дня",
+		"%d дней",
+	},
+	"<b>Records for the last %d days:</b>\n\n": {
+		"<b>Записи за последний %d день:</b>\n\n",
+		"<b>Записи за последние %d дня:</b>\n\n",
+		"<b>Записи за последние %d дней:</b>\n\n",
+	},
+	"No records found for the last %d days": {
+		"За последний %d день записей нет",
+		"За последние %d дня записей нет",
+		"За последние %d дней записей нет",
+	},
+	"✅ Imported %d products: %d new, %d updated": {
+		"✅ Импортирован %d продукт: новых - %d, обновлено - %d",
+		"✅ Импортировано %d продукта: новых - %d, обновлено - %d",
+		"✅ Импортировано %d продуктов: новых - %d, обновлено - %d",
+	},
+	"📈 Calories and macros for the last %d days": {
+		"📈 Калории и БЖУ за последний %d день",
+		"📈 Калории и БЖУ за последние %d дня",
+		"📈 Калории и БЖУ за последние %d дней",
+	},
+	"📊 Statistics for the last %d days\n\n": {
+		"📊 Статистика за последний %d день\n\n",
+		"📊 Статистика за последние %d дня\n\n",
+		"📊 Статистика за последние %d дней\n\n",
+	},
+	"📦 %d products": {
+		"📦 %d продукт",
+		"📦 %d продукта",
+		"📦 %d продуктов",
+	},
+}
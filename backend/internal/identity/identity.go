@@ -0,0 +1,57 @@
+// Package identity resolves the user behind an update. Users are keyed by
+// their numeric Telegram ID, which never changes, while their username is
+// only remembered as an attribute that may change or be missing.
+package identity
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"backend/internal/timeout"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const contextKey = "identity.user_id"
+
+// Middleware makes sure the sender of every update is a known user before
+// the handlers run. ensure stores the user with their current username; it
+// is only called again when the username changes, with the context of the
+// update. The user ID is then available through From.
+func Middleware(ensure func(ctx context.Context, id int64, username string) error) tele.MiddlewareFunc {
+	var known sync.Map // user ID → username last stored
+
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			user := c.Sender()
+			if user == nil {
+				return next(c)
+			}
+
+			if username, ok := known.Load(user.ID); !ok || username != user.Username {
+				if err := ensure(timeout.From(c), user.ID, user.Username); err != nil {
+					log.Printf("Error storing user %d: %v", user.ID, err)
+				} else {
+					known.Store(user.ID, user.Username)
+				}
+			}
+
+			c.Set(contextKey, user.ID)
+			return next(c)
+		}
+	}
+}
+
+// From returns the ID of the user behind the update, or 0 for updates
+// without a sender.
+func From(c tele.Context) int64 {
+	if id, ok := c.Get(contextKey).(int64); ok {
+		return id
+	}
+
+	if user := c.Sender(); user != nil {
+		return user.ID
+	}
+	return 0
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Meal categories of records.
+const (
+	MealBreakfast = "breakfast"
+	MealLunch     = "lunch"
+	MealDinner    = "dinner"
+	MealSnack     = "snack"
+)
+
+// Meals lists the meal categories in the order they happen during a day.
+var Meals = []string{MealBreakfast, MealLunch, MealDinner, MealSnack}
+
+// ParseMeal recognizes a meal category, optionally prefixed with '#'.
+func ParseMeal(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimPrefix(s, "#"))
+	for _, meal := range Meals {
+		if s == meal {
+			return meal, true
+		}
+	}
+	return "", false
+}
+
+// InferMeal guesses the meal of a record eaten at t. The clock time is
+// counted from the user's day flip, so food eaten after midnight but before
+// the flip is a late snack of the previous day rather than a breakfast.
+func InferMeal(t time.Time, flip time.Time) string {
+	flipMinutes := flip.Hour()*60 + flip.Minute()
+	minutes := t.Hour()*60 + t.Minute()
+	if minutes < flipMinutes {
+		minutes += 24 * 60
+	}
+
+	switch {
+	case minutes >= 5*60 && minutes < 11*60:
+		return MealBreakfast
+	case minutes >= 11*60 && minutes < 16*60:
+		return MealLunch
+	case minutes >= 16*60 && minutes < 22*60:
+		return MealDinner
+	default:
+		return MealSnack
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/google/uuid"
 )
 
+// ProductDetails is a catalog product. Products without an Owner are shared
+// between all users; Owner is the Telegram ID of the user they belong to.
 type ProductDetails struct {
 	UUID     uuid.UUID `json:"uuid" db:"uuid"`
 	Name     string    `json:"name" db:"name"`
@@ -13,25 +15,107 @@ type ProductDetails struct {
 	Fats     int64     `json:"fats" db:"fats"`
 	Proteins int64     `json:"proteins" db:"proteins"`
 	Carbs    int64     `json:"carbs" db:"carbs"`
+	Unit     string    `json:"unit" db:"unit"`
+	Owner    *int64    `json:"owner,omitempty" db:"owner"`
+	Brand    string    `json:"brand,omitempty" db:"brand"`
+	Barcode  string    `json:"barcode,omitempty" db:"barcode"`
 }
 
+// Record is an eaten amount of a product. An empty Meal is inferred from
+// CreatedAt when shown. The unit and nutrition are those of the product when
+// it was recorded, so that later changes to the product or its recipe leave
+// past days as they were.
 type Record struct {
 	UUID        uuid.UUID `json:"uuid" db:"uuid"`
 	ProductUUID uuid.UUID `json:"product_uuid" db:"product_uuid"`
-	Amount      int64     `json:"amount" db:"amount"`
-	Login       string    `json:"login" db:"login"`
+	Amount      float64   `json:"amount" db:"amount"`
+	UserID      int64     `json:"user_id" db:"user_id"`
+	Meal        string    `json:"meal,omitempty" db:"meal"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	Ccal        int64     `json:"ccal" db:"ccal"`
+	Fats        int64     `json:"fats" db:"fats"`
+	Proteins    int64     `json:"proteins" db:"proteins"`
+	Carbs       int64     `json:"carbs" db:"carbs"`
+	Unit        string    `json:"unit" db:"unit"`
 }
 
+// Recorded returns the product as it was when the record was made.
+func (r *Record) Recorded(product *ProductDetails) *ProductDetails {
+	recorded := *product
+	recorded.Ccal = r.Ccal
+	recorded.Fats = r.Fats
+	recorded.Proteins = r.Proteins
+	recorded.Carbs = r.Carbs
+	recorded.Unit = r.Unit
+	return &recorded
+}
+
+// Nutrition returns the nutrition of the recorded amount.
+func (r *Record) Nutrition() Nutrition {
+	return r.Recorded(&ProductDetails{}).Nutrition(r.Amount)
+}
+
+// DailyTotal is the summed nutrition of a user's records for one day.
+type DailyTotal struct {
+	Day       time.Time `json:"day" db:"day"`
+	Nutrition Nutrition `json:"nutrition"`
+	Records   int64     `json:"records" db:"records"`
+}
+
+// DefaultTimezone is used for users who have not set their own timezone.
+const DefaultTimezone = "Europe/Moscow"
+
+// UserPreferences holds per-user settings. Zero goals are not set and an
+// empty Lang means the user has not chosen a language.
 type UserPreferences struct {
-	Login string    `json:"login" db:"login"`
-	Noon  time.Time `json:"noon" db:"noon"`
-	Lang  string    `json:"lang" db:"lang"`
+	UserID       int64     `json:"user_id" db:"user_id"`
+	Noon         time.Time `json:"noon" db:"noon"`
+	Lang         string    `json:"lang" db:"lang"`
+	Timezone     string    `json:"timezone" db:"timezone"`
+	GoalKcal     int64     `json:"goal_kcal" db:"goal_kcal"`
+	GoalProteins int64     `json:"goal_proteins" db:"goal_proteins"`
+	GoalFats     int64     `json:"goal_fats" db:"goal_fats"`
+	GoalCarbs    int64     `json:"goal_carbs" db:"goal_carbs"`
+}
+
+// DefaultUserPreferences returns the preferences of a user without a
+// user_preferences row, matching the column defaults.
+func DefaultUserPreferences(userID int64) *UserPreferences {
+	return &UserPreferences{
+		UserID:   userID,
+		Timezone: DefaultTimezone,
+	}
+}
+
+// HasGoals reports whether the user has set any daily goal.
+func (p *UserPreferences) HasGoals() bool {
+	return p.GoalKcal > 0 || p.GoalProteins > 0 || p.GoalFats > 0 || p.GoalCarbs > 0
+}
+
+// Location returns the user's timezone, falling back to DefaultTimezone
+// when the stored name cannot be loaded.
+func (p *UserPreferences) Location() *time.Location {
+	if loc, err := time.LoadLocation(p.Timezone); err == nil {
+		return loc
+	}
+	if loc, err := time.LoadLocation(DefaultTimezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// UserSchedule holds the notification settings of a user. Reminders are
+// clock times in the user's timezone.
+type UserSchedule struct {
+	UserID       int64       `json:"user_id" db:"user_id"`
+	ChatID       int64       `json:"chat_id" db:"chat_id"`
+	DailySummary bool        `json:"daily_summary" db:"daily_summary"`
+	Reminders    []time.Time `json:"reminders" db:"reminders"`
 }
 
 type UserCommonItem struct {
 	UUID        uuid.UUID  `json:"uuid" db:"uuid"`
-	Login       string     `json:"login" db:"login"`
+	UserID      int64      `json:"user_id" db:"user_id"`
 	Path        string     `json:"path" db:"path"`
 	Name        string     `json:"name" db:"name"`
 	ProductUUID *uuid.UUID `json:"product_uuid,omitempty" db:"product_uuid"`
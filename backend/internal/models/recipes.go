@@ -0,0 +1,89 @@
+package models
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// Recipe is a home-cooked dish made of catalog products. The dish itself is
+// a gram product owned by the cook whose per-100 g nutrition is computed
+// from the ingredients and the cooked weight. A zero CookedWeight stands for
+// the weight of the ingredients, see Weight.
+type Recipe struct {
+	Product      *ProductDetails    `json:"product"`
+	CookedWeight float64            `json:"cooked_weight" db:"cooked_weight"`
+	Ingredients  []RecipeIngredient `json:"ingredients"`
+}
+
+// RecipeIngredient is an amount of a product, in the product's unit, that
+// goes into a recipe.
+type RecipeIngredient struct {
+	Product *ProductDetails `json:"product"`
+	Amount  float64         `json:"amount" db:"amount"`
+}
+
+// Total returns the nutrition of all ingredients together.
+func (r *Recipe) Total() Nutrition {
+	var total Nutrition
+	for _, ingredient := range r.Ingredients {
+		total = total.Add(ingredient.Product.Nutrition(ingredient.Amount))
+	}
+	return total
+}
+
+// RawWeight returns the weight of the gram and milliliter ingredients.
+func (r *Recipe) RawWeight() float64 {
+	var weight float64
+	for _, ingredient := range r.Ingredients {
+		if ingredient.Product.Unit != UnitPiece {
+			weight += ingredient.Amount
+		}
+	}
+	return weight
+}
+
+// Weight returns the cooked weight of the dish, which is its raw weight
+// unless the cook gave one.
+func (r *Recipe) Weight() float64 {
+	if r.CookedWeight > 0 {
+		return r.CookedWeight
+	}
+	return r.RawWeight()
+}
+
+// Ingredient returns the index of the ingredient made of productUUID, or -1.
+func (r *Recipe) Ingredient(productUUID uuid.UUID) int {
+	for i, ingredient := range r.Ingredients {
+		if ingredient.Product.UUID == productUUID {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetIngredient adds amount of product to the recipe, replacing the amount
+// of an ingredient already made of it.
+func (r *Recipe) SetIngredient(product *ProductDetails, amount float64) {
+	if i := r.Ingredient(product.UUID); i != -1 {
+		r.Ingredients[i] = RecipeIngredient{Product: product, Amount: amount}
+		return
+	}
+	r.Ingredients = append(r.Ingredients, RecipeIngredient{Product: product, Amount: amount})
+}
+
+// Compute stores the per-100 g nutrition of the cooked dish in its product.
+// Calories are kept positive as the catalog requires.
+func (r *Recipe) Compute() {
+	total := r.Total()
+	factor := 0.0
+	if weight := r.Weight(); weight > 0 {
+		factor = 100 / weight
+	}
+
+	r.Product.Unit = UnitGram
+	r.Product.Ccal = int64(math.Max(1, math.Round(total.Kcal*factor)))
+	r.Product.Proteins = int64(math.Round(total.Proteins * factor))
+	r.Product.Fats = int64(math.Round(total.Fats * factor))
+	r.Product.Carbs = int64(math.Round(total.Carbs * factor))
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Product units. Nutrition of gram and milliliter products is stored per
+// 100 g/ml, nutrition of piece products per single piece.
+const (
+	UnitGram       = "g"
+	UnitMilliliter = "ml"
+	UnitPiece      = "pcs"
+)
+
+// Nutrition is the energy and macros of an eaten amount of a product.
+type Nutrition struct {
+	Kcal     float64
+	Proteins float64
+	Fats     float64
+	Carbs    float64
+}
+
+// Add returns the sum of two nutrition values.
+func (n Nutrition) Add(other Nutrition) Nutrition {
+	return Nutrition{
+		Kcal:     n.Kcal + other.Kcal,
+		Proteins: n.Proteins + other.Proteins,
+		Fats:     n.Fats + other.Fats,
+		Carbs:    n.Carbs + other.Carbs,
+	}
+}
+
+// Nutrition returns the nutrition of amount units of the product.
+func (p *ProductDetails) Nutrition(amount float64) Nutrition {
+	factor := amount
+	if p.Unit == UnitGram || p.Unit == UnitMilliliter {
+		factor = amount / 100
+	}
+
+	return Nutrition{
+		Kcal:     float64(p.Ccal) * factor,
+		Proteins: float64(p.Proteins) * factor,
+		Fats:     float64(p.Fats) * factor,
+		Carbs:    float64(p.Carbs) * factor,
+	}
+}
+
+var unitAliases = map[string]string{
+	"g":   UnitGram,
+	"gr":  UnitGram,
+	"ml":  UnitMilliliter,
+	"pcs": UnitPiece,
+	"pc":  UnitPiece,
+	"x":   UnitPiece,
+	"г":   UnitGram,
+	"гр":  UnitGram,
+	"мл":  UnitMilliliter,
+	"шт":  UnitPiece,
+}
+
+// ParseUnit recognizes a unit or one of its aliases, e.g. "gr" or "pc".
+func ParseUnit(s string) (string, bool) {
+	unit, ok := unitAliases[strings.ToLower(strings.TrimSpace(s))]
+	return unit, ok
+}
+
+// ParseAmount parses amounts such as "250g", "300ml" or "2pcs". A bare
+// number is returned with an empty unit.
+func ParseAmount(s string) (float64, string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != ','
+	})
+	if i == -1 {
+		i = len(s)
+	}
+
+	number := strings.ReplaceAll(s[:i], ",", ".")
+	amount, err := strconv.ParseFloat(number, 64)
+	if err != nil || amount <= 0 || math.IsInf(amount, 0) {
+		return 0, "", errors.New("Invalid amount. Use a positive number with an optional unit: g, ml or pcs")
+	}
+
+	suffix := s[i:]
+	if suffix == "" {
+		return amount, "", nil
+	}
+
+	unit, ok := unitAliases[suffix]
+	if !ok {
+		return 0, "", errors.New("Unknown unit. Use g, ml or pcs")
+	}
+
+	return amount, unit, nil
+}
+
+// FormatAmount renders an amount together with its unit, e.g. "250 g".
+func FormatAmount(amount float64, unit string) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64) + " " + unit
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ParseWhen extracts a time qualifier from fields and returns the moment it
+// refers to along with the remaining fields. Supported qualifiers are
+// "yesterday", "yesterday 20:30", "@08:15" (the latest 08:15 up to now) and
+// "-2h" / "-30m" / "-1d" (that long ago). now must be in the user's
+// timezone; "yesterday" is the day before the user's current day, which
+// starts at flip. A zero time means no qualifier was given.
+func ParseWhen(fields []string, now time.Time, flip time.Time) (time.Time, []string, error) {
+	var when time.Time
+	var rest []string
+
+	for i := 0; i < len(fields); i++ {
+		field := strings.ToLower(fields[i])
+
+		var t time.Time
+		switch {
+		case field == "yesterday":
+			clock := time.Time{}
+			hasClock := false
+			if i+1 < len(fields) {
+				if parsed, err := time.Parse("15:04", fields[i+1]); err == nil {
+					clock, hasClock = parsed, true
+					i++
+				}
+			}
+			t = yesterday(now, flip, clock, hasClock)
+
+		case strings.HasPrefix(field, "@"):
+			clock, parseErr := time.Parse("15:04", field[1:])
+			if parseErr != nil {
+				return time.Time{}, nil, errors.New("Invalid time. Use @HH:MM, e.g. @08:15")
+			}
+			t = latestAt(now, clock)
+
+		case strings.HasPrefix(field, "-") && len(field) > 1:
+			ago, parseErr := parseAgo(field[1:])
+			if errors.Is(parseErr, errTooLongAgo) {
+				return time.Time{}, nil, parseErr
+			}
+			if parseErr != nil {
+				rest = append(rest, fields[i])
+				continue
+			}
+			t = now.Add(-ago)
+
+		default:
+			rest = append(rest, fields[i])
+			continue
+		}
+
+		if !when.IsZero() {
+			return time.Time{}, nil, errors.New("Only one time qualifier is allowed")
+		}
+		when = t
+	}
+
+	if when.After(now) {
+		return time.Time{}, nil, errors.New("The time cannot be in the future")
+	}
+
+	return when, rest, nil
+}
+
+// latestAt returns the latest moment up to now with the clock time of clock.
+func latestAt(now time.Time, clock time.Time) time.Time {
+	t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	if t.After(now) {
+		t = time.Date(now.Year(), now.Month(), now.Day()-1, clock.Hour(), clock.Minute(), 0, 0, now.Location())
+	}
+	return t
+}
+
+// yesterday returns the moment with the given clock time during the day
+// before the current one, or the same moment a day ago without a clock.
+func yesterday(now time.Time, flip time.Time, clock time.Time, hasClock bool) time.Time {
+	if !hasClock {
+		return now.AddDate(0, 0, -1)
+	}
+
+	// The current day starts at the latest flip; yesterday ends there.
+	todayStart := latestAt(now, flip)
+	return latestAt(todayStart.Add(-time.Nanosecond), clock)
+}
+
+// maxAgoDays bounds how long ago a record can be logged.
+const maxAgoDays = 366
+
+var errTooLongAgo = errors.New("The time can be at most 366 days ago")
+
+// parseAgo parses durations like "2h", "30m", "1h30m" and "2d" of up to
+// maxAgoDays.
+func parseAgo(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		if _, err := fmt.Sscanf(days, "%d", &n); err != nil || n <= 0 || fmt.Sprint(n) != days {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		if n > maxAgoDays {
+			return 0, errTooLongAgo
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	if d > maxAgoDays*24*time.Hour {
+		return 0, errTooLongAgo
+	}
+	return d, nil
+}
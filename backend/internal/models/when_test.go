@@ -0,0 +1,92 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAgo(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr error
+	}{
+		{in: "2h", want: 2 * time.Hour},
+		{in: "1h30m", want: 90 * time.Minute},
+		{in: "2d", want: 48 * time.Hour},
+		{in: "366d", want: 366 * 24 * time.Hour},
+		{in: "8784h", want: 8784 * time.Hour},
+		{in: "367d", wantErr: errTooLongAgo},
+		{in: "8785h", wantErr: errTooLongAgo},
+		{in: "99999h", wantErr: errTooLongAgo},
+		// Would overflow time.Duration when multiplied by a day.
+		{in: "106752d", wantErr: errTooLongAgo},
+		{in: "9223372036854775807d", wantErr: errTooLongAgo},
+		{in: "0d"},
+		{in: "0h"},
+		{in: "d"},
+		{in: "02d"},
+		{in: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseAgo(tt.in)
+			if tt.want == 0 {
+				if err == nil {
+					t.Fatalf("parseAgo(%q) = %v, want an error", tt.in, got)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("parseAgo(%q) error = %v, want %v", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseAgo(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWhen(t *testing.T) {
+	now := time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC)
+	flip := time.Date(0, 1, 1, 4, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		in      string
+		want    time.Time
+		rest    string
+		wantErr string
+	}{
+		{in: "Apple 2pcs", rest: "Apple 2pcs"},
+		{in: "Apple -2h", want: now.Add(-2 * time.Hour), rest: "Apple"},
+		{in: "Apple -366d", want: now.Add(-366 * 24 * time.Hour), rest: "Apple"},
+		{in: "Apple @08:15", want: time.Date(2024, 3, 10, 8, 15, 0, 0, time.UTC), rest: "Apple"},
+		{in: "Apple yesterday 20:30", want: time.Date(2024, 3, 9, 20, 30, 0, 0, time.UTC), rest: "Apple"},
+		{in: "Apple -367d", wantErr: "The time can be at most 366 days ago"},
+		{in: "Apple -99999h", wantErr: "The time can be at most 366 days ago"},
+		{in: "Apple -2h @08:15", wantErr: "Only one time qualifier is allowed"},
+		// Not a duration, so it is part of the name.
+		{in: "Mix -abc", rest: "Mix -abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, rest, err := ParseWhen(strings.Fields(tt.in), now, flip)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ParseWhen(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseWhen(%q) failed: %v", tt.in, err)
+			}
+			if !got.Equal(tt.want) || strings.Join(rest, " ") != tt.rest {
+				t.Errorf("ParseWhen(%q) = %v, %q; want %v, %q", tt.in, got, rest, tt.want, tt.rest)
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+// Package openfoodfacts reads products from Open Food Facts data exports,
+// either the tab-separated CSV export or the JSONL dump, optionally gzipped.
+package openfoodfacts
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"backend/internal/barcode"
+	"backend/internal/models"
+)
+
+// Export formats.
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// kjPerKcal converts energy given only in kilojoules.
+const kjPerKcal = 4.184
+
+// Stats counts the outcome of reading an export.
+type Stats struct {
+	Read    int
+	Skipped int
+}
+
+// ReadFile reads the export at path, detecting the format from the file
+// name, and calls fn with every usable product.
+func ReadFile(path string, fn func(*models.ProductDetails) error) (Stats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	name := strings.ToLower(path)
+	if trimmed, ok := strings.CutSuffix(name, ".gz"); ok {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return Stats{}, err
+		}
+		defer gz.Close()
+		r, name = gz, trimmed
+	}
+
+	format := FormatCSV
+	if strings.HasSuffix(name, ".jsonl") || strings.HasSuffix(name, ".json") {
+		format = FormatJSONL
+	}
+
+	return Read(r, format, fn)
+}
+
+// Read reads an export in the given format and calls fn with every product
+// that has a valid barcode, a name and plausible nutrition per 100 g/ml.
+// Other rows are counted as skipped.
+func Read(r io.Reader, format string, fn func(*models.ProductDetails) error) (Stats, error) {
+	switch format {
+	case FormatCSV:
+		return readCSV(r, fn)
+	case FormatJSONL:
+		return readJSONL(r, fn)
+	}
+	return Stats{}, fmt.Errorf("unknown format %q", format)
+}
+
+// row is a product of either format by OFF field name.
+type row func(field string) string
+
+func readCSV(r io.Reader, fn func(*models.ProductDetails) error) (Stats, error) {
+	reader := bufio.NewReaderSize(r, 1<<16)
+
+	header, err := reader.ReadString('\n')
+	if err != nil && header == "" {
+		return Stats{}, err
+	}
+	header = strings.TrimRight(header, "\r\n")
+
+	// The OFF export is tab separated without quoting, so quotes inside
+	// names must not be interpreted. Comma separated files are real CSV.
+	var next func() ([]string, error)
+	var columns []string
+	if strings.Contains(header, "\t") {
+		columns = strings.Split(header, "\t")
+		next = func() ([]string, error) {
+			line, err := reader.ReadString('\n')
+			if line == "" && err != nil {
+				return nil, err
+			}
+			return strings.Split(strings.TrimRight(line, "\r\n"), "\t"), nil
+		}
+	} else {
+		csvReader := csv.NewReader(io.MultiReader(strings.NewReader(header+"\n"), reader))
+		csvReader.FieldsPerRecord = -1
+		csvReader.LazyQuotes = true
+		columns, err = csvReader.Read()
+		if err != nil {
+			return Stats{}, err
+		}
+		next = csvReader.Read
+	}
+
+	index := make(map[string]int, len(columns))
+	for i, column := range columns {
+		index[strings.TrimPrefix(strings.TrimSpace(column), "\ufeff")] = i
+	}
+	if _, ok := index["code"]; !ok {
+		return Stats{}, errors.New("no code column in the header")
+	}
+
+	var stats Stats
+	for {
+		fields, err := next()
+		if err == io.EOF {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		stats.Read++
+		product := parseRow(func(field string) string {
+			if i, ok := index[field]; ok && i < len(fields) {
+				return fields[i]
+			}
+			return ""
+		}, columns)
+		if product == nil {
+			stats.Skipped++
+			continue
+		}
+		if err := fn(product); err != nil {
+			return stats, err
+		}
+	}
+}
+
+func readJSONL(r io.Reader, fn func(*models.ProductDetails) error) (Stats, error) {
+	decoder := json.NewDecoder(bufio.NewReaderSize(r, 1<<16))
+	decoder.UseNumber()
+
+	var stats Stats
+	for {
+		var object map[string]interface{}
+		err := decoder.Decode(&object)
+		if err == io.EOF {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		stats.Read++
+		nutriments, _ := object["nutriments"].(map[string]interface{})
+		var keys []string
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		product := parseRow(func(field string) string {
+			value, ok := object[field]
+			if !ok && nutriments != nil {
+				value = nutriments[field]
+			}
+			switch v := value.(type) {
+			case string:
+				return v
+			case json.Number:
+				return v.String()
+			}
+			return ""
+		}, keys)
+		if product == nil {
+			stats.Skipped++
+			continue
+		}
+		if err := fn(product); err != nil {
+			return stats, err
+		}
+	}
+}
+
+// parseRow turns a row into a shared catalog product, or nil if it cannot
+// be used. fields lists the names of the row's fields, which are searched
+// for a localized name when product_name is empty.
+func parseRow(get row, fields []string) *models.ProductDetails {
+	code := strings.TrimSpace(get("code"))
+	if !barcode.Valid(code) {
+		return nil
+	}
+
+	name := strings.TrimSpace(get("product_name"))
+	for _, field := range fields {
+		if name != "" {
+			break
+		}
+		if strings.HasPrefix(field, "product_name_") {
+			name = strings.TrimSpace(get(field))
+		}
+	}
+	if name == "" {
+		return nil
+	}
+
+	kcal, ok := number(get("energy-kcal_100g"))
+	if !ok {
+		kj, ok := number(get("energy_100g"))
+		if !ok {
+			return nil
+		}
+		kcal = kj / kjPerKcal
+	}
+	proteins, okProteins := number(get("proteins_100g"))
+	fats, okFats := number(get("fat_100g"))
+	carbs, okCarbs := number(get("carbohydrates_100g"))
+
+	// Energy beyond pure fat or macros beyond 100 g are data entry errors.
+	if math.Round(kcal) < 1 || kcal > 900 {
+		return nil
+	}
+	for _, macro := range []struct {
+		value float64
+		ok    bool
+	}{{proteins, okProteins}, {fats, okFats}, {carbs, okCarbs}} {
+		if macro.ok && (macro.value < 0 || macro.value > 100) {
+			return nil
+		}
+	}
+
+	brand, _, _ := strings.Cut(get("brands"), ",")
+
+	return &models.ProductDetails{
+		Name:     truncate(name, 255),
+		Ccal:     int64(math.Round(kcal)),
+		Proteins: int64(math.Round(proteins)),
+		Fats:     int64(math.Round(fats)),
+		Carbs:    int64(math.Round(carbs)),
+		Unit:     unit(get("quantity")),
+		Brand:    truncate(strings.TrimSpace(brand), 255),
+		Barcode:  code,
+	}
+}
+
+func number(s string) (float64, bool) {
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
+
+// unit guesses whether nutrition is per 100 ml from the package quantity,
+// e.g. "1 l" or "330 ml". OFF gives drinks per 100 ml under the _100g keys.
+func unit(quantity string) string {
+	quantity = strings.ToLower(strings.TrimSpace(quantity))
+	for _, suffix := range []string{"ml", "cl", "dl", "l"} {
+		if strings.HasSuffix(quantity, suffix) {
+			value := strings.TrimSpace(strings.TrimSuffix(quantity, suffix))
+			if _, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64); err == nil {
+				return models.UnitMilliliter
+			}
+		}
+	}
+	return models.UnitGram
+}
+
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit])
+}
@@ -0,0 +1,124 @@
+package openfoodfacts
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backend/internal/models"
+)
+
+// want are the usable products of the fixtures in testdata; the other four
+// rows have a bad barcode, no name, too much energy and too much protein.
+var want = []models.ProductDetails{
+	{Name: "Oat Flakes", Ccal: 372, Proteins: 14, Fats: 7, Carbs: 59, Unit: models.UnitGram, Brand: "Kölln", Barcode: "4006381333931"},
+	// Energy only in kilojoules, per 100 ml by the package quantity.
+	{Name: `Orange Juice "Fresh"`, Ccal: 45, Proteins: 1, Fats: 0, Carbs: 10, Unit: models.UnitMilliliter, Barcode: "5901234123457"},
+	// A localized name and a UPC-A barcode.
+	{Name: "Biscuits", Ccal: 480, Proteins: 6, Fats: 24, Carbs: 60, Unit: models.UnitGram, Barcode: "036000291452"},
+}
+
+func readAll(t *testing.T, path string) ([]models.ProductDetails, Stats) {
+	t.Helper()
+	var products []models.ProductDetails
+	stats, err := ReadFile(path, func(product *models.ProductDetails) error {
+		products = append(products, *product)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadFile(%s) failed: %v", path, err)
+	}
+	return products, stats
+}
+
+func checkProducts(t *testing.T, got []models.ProductDetails, stats Stats) {
+	t.Helper()
+	if stats != (Stats{Read: 7, Skipped: 4}) {
+		t.Errorf("stats %+v, want 7 read and 4 skipped", stats)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d products, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("product %d:\n%+v\nwant:\n%+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	for _, name := range []string{"products.csv", "products.jsonl"} {
+		t.Run(name, func(t *testing.T) {
+			products, stats := readAll(t, filepath.Join("testdata", name))
+			checkProducts(t, products, stats)
+		})
+	}
+}
+
+func TestReadFileGzip(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "products.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "products.jsonl.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(file)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	products, stats := readAll(t, path)
+	checkProducts(t, products, stats)
+}
+
+func TestReadCommaSeparated(t *testing.T) {
+	// Comma separated files are real CSV with quoted fields.
+	input := "\ufeffcode,product_name,brands,energy-kcal_100g,proteins_100g,fat_100g,carbohydrates_100g\n" +
+		`4006381333931,"Oat Flakes, Fine","Kölln",372,13.5,7,58.7` + "\n"
+
+	var products []models.ProductDetails
+	stats, err := Read(strings.NewReader(input), FormatCSV, func(product *models.ProductDetails) error {
+		products = append(products, *product)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if stats != (Stats{Read: 1}) || len(products) != 1 || products[0].Name != "Oat Flakes, Fine" || products[0].Brand != "Kölln" {
+		t.Errorf("Read = %+v, %+v; want Oat Flakes, Fine by Kölln", products, stats)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		want   string
+	}{
+		{"no code column", FormatCSV, "product_name\tenergy_100g\nOats\t1500\n", "no code column in the header"},
+		{"broken JSON", FormatJSONL, `{"code": "4006381333931"`, "unexpected EOF"},
+		{"unknown format", "xml", "", `unknown format "xml"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input), tt.format, func(*models.ProductDetails) error { return nil })
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Read error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
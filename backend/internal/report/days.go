@@ -0,0 +1,49 @@
+package report
+
+import "time"
+
+// Day is a single calendar day of a user. It starts at the user's day-flip
+// time and lasts until the next flip.
+type Day struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t falls into the day.
+func (d Day) Contains(t time.Time) bool {
+	return !t.Before(d.Start) && t.Before(d.End)
+}
+
+// Label returns the short date the day is displayed under.
+func (d Day) Label() string {
+	return d.Start.Format("02-01")
+}
+
+// DayStart returns the beginning of the day containing t for the given
+// day-flip time. Only the clock part of flip is used.
+func DayStart(t time.Time, flip time.Time) time.Time {
+	start := time.Date(t.Year(), t.Month(), t.Day(), flip.Hour(), flip.Minute(), 0, 0, t.Location())
+	if start.After(t) {
+		start = time.Date(t.Year(), t.Month(), t.Day()-1, flip.Hour(), flip.Minute(), 0, 0, t.Location())
+	}
+	return start
+}
+
+// DayOf returns the day containing t.
+func DayOf(t time.Time, flip time.Time) Day {
+	start := DayStart(t, flip)
+	end := time.Date(start.Year(), start.Month(), start.Day()+1, flip.Hour(), flip.Minute(), 0, 0, start.Location())
+	return Day{Start: start, End: end}
+}
+
+// LastDays returns n consecutive days ending with the day containing now,
+// oldest first.
+func LastDays(now time.Time, flip time.Time, n int) []Day {
+	days := make([]Day, n)
+	current := DayOf(now, flip)
+	for i := n - 1; i >= 0; i-- {
+		days[i] = current
+		current = DayOf(current.Start.Add(-time.Nanosecond), flip)
+	}
+	return days
+}
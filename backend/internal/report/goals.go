@@ -0,0 +1,58 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"backend/internal/i18n"
+	"backend/internal/models"
+)
+
+const progressWidth = 10
+
+// GoalProgress renders consumed nutrition against the user's daily goals,
+// one line per goal that is set. It returns an empty string without goals.
+func GoalProgress(p *i18n.Printer, consumed models.Nutrition, prefs *models.UserPreferences) string {
+	goals := []struct {
+		name   string
+		unit   string
+		value  float64
+		target int64
+	}{
+		{p.T("kcal"), "", consumed.Kcal, prefs.GoalKcal},
+		{p.T("protein"), p.T("g"), consumed.Proteins, prefs.GoalProteins},
+		{p.T("fat"), p.T("g"), consumed.Fats, prefs.GoalFats},
+		{p.T("carbs"), p.T("g"), consumed.Carbs, prefs.GoalCarbs},
+	}
+
+	var result strings.Builder
+	for _, goal := range goals {
+		if goal.target <= 0 {
+			continue
+		}
+
+		left := float64(goal.target) - goal.value
+		remaining := p.Sprintf("%.0f%s left", left, goal.unit)
+		if left < 0 {
+			remaining = p.Sprintf("%.0f%s over", -left, goal.unit)
+		}
+
+		result.WriteString(fmt.Sprintf("%-8s %5.0f/%-5d %s %3.0f%% · %s\n",
+			goal.name, goal.value, goal.target, ProgressBar(goal.value, float64(goal.target), progressWidth),
+			goal.value/float64(goal.target)*100, remaining))
+	}
+
+	return result.String()
+}
+
+// ProgressBar renders value/target as a bar of width cells, capped at full.
+func ProgressBar(value, target float64, width int) string {
+	filled := 0
+	if target > 0 {
+		filled = int(math.Round(value / target * float64(width)))
+	}
+	filled = max(0, min(filled, width))
+
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
@@ -0,0 +1,34 @@
+package report
+
+import (
+	"strings"
+
+	"backend/internal/i18n"
+	"backend/internal/models"
+)
+
+// MealBreakdown renders how much of the energy of entries each meal
+// contributed. Meals without entries are left out.
+func MealBreakdown(p *i18n.Printer, entries []Entry) string {
+	byMeal := make(map[string]float64)
+	total := 0.0
+	for _, entry := range entries {
+		byMeal[entry.Meal] += entry.Nutrition.Kcal
+		total += entry.Nutrition.Kcal
+	}
+	if total <= 0 {
+		return ""
+	}
+
+	var result strings.Builder
+	for _, meal := range models.Meals {
+		kcal, ok := byMeal[meal]
+		if !ok {
+			continue
+		}
+		result.WriteString(p.Sprintf("%-9s %5.0f kcal %3.0f%% %s\n",
+			p.T(meal), kcal, kcal/total*100, ProgressBar(kcal, total, progressWidth)))
+	}
+
+	return result.String()
+}
@@ -0,0 +1,115 @@
+package report
+
+import (
+	"math"
+	"strings"
+
+	"backend/internal/i18n"
+	"backend/internal/models"
+)
+
+// Stats summarizes the daily totals of a period. Best and Worst are nil
+// without logged days.
+type Stats struct {
+	Days          int
+	LoggedDays    int
+	Average       models.Nutrition
+	Best          *models.DailyTotal
+	Worst         *models.DailyTotal
+	CurrentStreak int
+	LongestStreak int
+}
+
+// ComputeStats summarizes totals over days. Averages only count days with
+// records. The best day is the one closest to the kcal goal and the worst
+// the farthest away; without a goal they are the lowest and highest kcal
+// days.
+func ComputeStats(days []Day, totals []*models.DailyTotal, goalKcal int64) Stats {
+	byDate := make(map[string]*models.DailyTotal, len(totals))
+	for _, total := range totals {
+		byDate[total.Day.Format("2006-01-02")] = total
+	}
+
+	stats := Stats{Days: len(days)}
+	score := func(total *models.DailyTotal) float64 {
+		if goalKcal <= 0 {
+			return total.Nutrition.Kcal
+		}
+		return math.Abs(total.Nutrition.Kcal - float64(goalKcal))
+	}
+
+	logged := func(day Day) *models.DailyTotal {
+		if total, ok := byDate[day.Start.Format("2006-01-02")]; ok && total.Records > 0 {
+			return total
+		}
+		return nil
+	}
+
+	var sum models.Nutrition
+	streak := 0
+	for _, day := range days {
+		total := logged(day)
+		if total == nil {
+			streak = 0
+			continue
+		}
+
+		stats.LoggedDays++
+		sum = sum.Add(total.Nutrition)
+
+		streak++
+		stats.LongestStreak = max(stats.LongestStreak, streak)
+
+		if stats.Best == nil || score(total) < score(stats.Best) {
+			stats.Best = total
+		}
+		if stats.Worst == nil || score(total) > score(stats.Worst) {
+			stats.Worst = total
+		}
+	}
+
+	// Today may simply not be logged yet, so it does not break the streak.
+	last := len(days) - 1
+	if last > 0 && logged(days[last]) == nil {
+		last--
+	}
+	for i := last; i >= 0 && logged(days[i]) != nil; i-- {
+		stats.CurrentStreak++
+	}
+
+	if stats.LoggedDays > 0 {
+		n := float64(stats.LoggedDays)
+		stats.Average = models.Nutrition{
+			Kcal:     sum.Kcal / n,
+			Proteins: sum.Proteins / n,
+			Fats:     sum.Fats / n,
+			Carbs:    sum.Carbs / n,
+		}
+	}
+
+	return stats
+}
+
+// Format renders the statistics as a plain text summary.
+func (s Stats) Format(p *i18n.Printer) string {
+	var result strings.Builder
+	result.WriteString(p.Sprintf("📆 Logged days: %d of %d\n", s.LoggedDays, s.Days))
+	if s.LoggedDays == 0 {
+		return result.String()
+	}
+
+	result.WriteString(p.Sprintf("🔥 Daily average: %.0f kcal\n", s.Average.Kcal))
+	result.WriteString(p.Sprintf("🥩 Average macros: P %.0f g · F %.0f g · C %.0f g\n",
+		s.Average.Proteins, s.Average.Fats, s.Average.Carbs))
+	if split := EnergySplit(p, s.Average); split != "" {
+		result.WriteString(p.T("⚡ Energy: ") + split + "\n")
+	}
+	if s.Best != nil {
+		result.WriteString(p.Sprintf("🏆 Best day: %s · %.0f kcal\n", s.Best.Day.Format("02-01"), s.Best.Nutrition.Kcal))
+		result.WriteString(p.Sprintf("📉 Worst day: %s · %.0f kcal\n", s.Worst.Day.Format("02-01"), s.Worst.Nutrition.Kcal))
+	}
+	result.WriteString(p.T("🔗 Current streak: ") + p.Plural(s.CurrentStreak, "%d day", "%d days") +
+		p.T(" · longest: ") + p.Plural(s.LongestStreak, "%d day", "%d days") + "\n")
+
+	return result.String()
+}
@@ -0,0 +1,34 @@
+package report
+
+import (
+	"html"
+	"strings"
+
+	"backend/internal/i18n"
+	"backend/internal/models"
+)
+
+// DaySummary renders the entries of a day with totals and goal progress as
+// an HTML message.
+func DaySummary(p *i18n.Printer, group DayEntries, prefs *models.UserPreferences) string {
+	var result strings.Builder
+	result.WriteString(p.Sprintf("<b>Summary for %s</b>\n\n", group.Day.Label()))
+
+	if len(group.Entries) == 0 {
+		result.WriteString(p.T("No records for this day."))
+		return result.String()
+	}
+
+	result.WriteString("<pre>" + html.EscapeString(Table(p, group.Entries)) + "</pre>\n")
+
+	total := group.Total()
+	result.WriteString(p.Sprintf("\n📋 <b>Total: %s</b>", FormatTotal(p, total)))
+	if meals := MealBreakdown(p, group.Entries); meals != "" {
+		result.WriteString(p.T("\n\n🍽 <b>Meals</b>\n") + "<pre>" + meals + "</pre>")
+	}
+	if progress := GoalProgress(p, total, prefs); progress != "" {
+		result.WriteString(p.T("\n\n🎯 <b>Goals</b>\n") + "<pre>" + progress + "</pre>")
+	}
+
+	return result.String()
+}
@@ -0,0 +1,121 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/i18n"
+	"backend/internal/models"
+)
+
+const (
+	nameWidth = 12
+	separator = "──────┼────────────┼────┼────┼────┼────\n"
+)
+
+// tableHeader must keep the column widths of separator in every language.
+const tableHeader = " time │    name    │kcal│  P │  F │  C\n"
+
+// Entry is a single eaten record as shown in reports.
+type Entry struct {
+	Time      time.Time
+	Name      string
+	Meal      string
+	Nutrition models.Nutrition
+}
+
+// DayEntries are the entries of one day in chronological order.
+type DayEntries struct {
+	Day     Day
+	Entries []Entry
+}
+
+// Total returns the summed nutrition of the day.
+func (d DayEntries) Total() models.Nutrition {
+	var total models.Nutrition
+	for _, entry := range d.Entries {
+		total = total.Add(entry.Nutrition)
+	}
+	return total
+}
+
+// Group sorts entries into days, dropping entries outside of all days.
+// Every day is returned, including days without entries.
+func Group(days []Day, entries []Entry) []DayEntries {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+
+	groups := make([]DayEntries, len(days))
+	for i, day := range days {
+		groups[i].Day = day
+		for _, entry := range sorted {
+			if day.Contains(entry.Time) {
+				groups[i].Entries = append(groups[i].Entries, entry)
+			}
+		}
+	}
+	return groups
+}
+
+// Table renders entries with their energy and macros, followed by a total
+// row and the energy split. The result is meant for a <pre> block.
+func Table(p *i18n.Printer, entries []Entry) string {
+	var result strings.Builder
+	result.WriteString(p.T(tableHeader))
+	result.WriteString(separator)
+
+	var total models.Nutrition
+	for _, entry := range entries {
+		total = total.Add(entry.Nutrition)
+		result.WriteString(fmt.Sprintf("%s │%s│%s\n", entry.Time.Format("15:04"), fitText(entry.Name, nameWidth), nutritionCells(entry.Nutrition)))
+	}
+
+	result.WriteString(separator)
+	result.WriteString(fmt.Sprintf("  Σ   │%s│%s\n", strings.Repeat(" ", nameWidth), nutritionCells(total)))
+	if split := EnergySplit(p, total); split != "" {
+		result.WriteString(split + "\n")
+	}
+
+	return result.String()
+}
+
+// EnergySplit returns the share of energy coming from proteins, fats and
+// carbs (4, 9 and 4 kcal per gram).
+func EnergySplit(p *i18n.Printer, n models.Nutrition) string {
+	proteins := n.Proteins * 4
+	fats := n.Fats * 9
+	carbs := n.Carbs * 4
+
+	sum := proteins + fats + carbs
+	if sum <= 0 {
+		return ""
+	}
+
+	return p.Sprintf("P %.0f%% · F %.0f%% · C %.0f%%", proteins/sum*100, fats/sum*100, carbs/sum*100)
+}
+
+// FormatTotal renders nutrition as "1450 kcal · P 80 g · F 50 g · C 160 g".
+func FormatTotal(p *i18n.Printer, n models.Nutrition) string {
+	return p.Sprintf("%.0f kcal · P %.0f g · F %.0f g · C %.0f g", n.Kcal, n.Proteins, n.Fats, n.Carbs)
+}
+
+func nutritionCells(n models.Nutrition) string {
+	return fmt.Sprintf("%4.0f│%4.0f│%4.0f│%4.0f", n.Kcal, n.Proteins, n.Fats, n.Carbs)
+}
+
+// fitText truncates s to width runes and pads it with spaces on both sides.
+func fitText(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) > width {
+		runes = runes[:width]
+	}
+
+	length := len(runes)
+	padding := (width - length) / 2
+	return strings.Repeat(" ", padding) + string(runes) + strings.Repeat(" ", width-length-padding)
+}
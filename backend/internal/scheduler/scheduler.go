@@ -0,0 +1,226 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"backend/internal/i18n"
+	"backend/internal/models"
+	"backend/internal/report"
+	"backend/internal/storage"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const (
+	// Interval is how often schedules are checked.
+	Interval = time.Minute
+
+	// summaryWindow is how late a daily summary is still sent, e.g. after
+	// the bot was down during the day flip.
+	summaryWindow = 6 * time.Hour
+
+	// reminderWindow is how late a reminder is still sent.
+	reminderWindow = 30 * time.Minute
+
+	// userTimeout bounds the work for a single user in a tick, so that a
+	// slow database delays the others rather than starving them.
+	userTimeout = 10 * time.Second
+
+	summaryKind = "summary"
+)
+
+// Sender sends messages to chats; *tele.Bot implements it.
+type Sender interface {
+	Send(to tele.Recipient, what interface{}, opts ...interface{}) (*tele.Message, error)
+}
+
+// Store is the data the scheduler works with; storage.Store implements it.
+type Store interface {
+	storage.Products
+	storage.Records
+	storage.Preferences
+	storage.Schedules
+}
+
+// Scheduler sends daily summaries at each user's day flip and reminders at
+// their meal times when nothing was logged.
+type Scheduler struct {
+	db     Store
+	sender Sender
+}
+
+func New(db Store, sender Sender) *Scheduler {
+	return &Scheduler{db: db, sender: sender}
+}
+
+// Run checks the schedules every Interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(Interval)
+	defer ticker.Stop()
+
+	s.tick(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.tick(ctx, now)
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context, now time.Time) {
+	schedulesCtx, cancel := context.WithTimeout(ctx, Interval)
+	defer cancel()
+
+	schedules, err := s.db.GetUserSchedules(schedulesCtx)
+	if err != nil {
+		log.Printf("Error getting schedules: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		if ctx.Err() != nil {
+			return
+		}
+		s.notifyUser(ctx, schedule, now)
+	}
+}
+
+// notifyUser sends whatever notifications of schedule are due at now.
+// Notifications that time out are not claimed and are tried again on the
+// next tick.
+func (s *Scheduler) notifyUser(ctx context.Context, schedule *models.UserSchedule, now time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, userTimeout)
+	defer cancel()
+
+	prefs, err := s.db.GetUserPreferencesOrDefault(ctx, schedule.UserID)
+	if err != nil {
+		log.Printf("Error getting preferences of %d: %v", schedule.UserID, err)
+		return
+	}
+
+	local := now.In(prefs.Location())
+	if schedule.DailySummary {
+		s.sendSummary(ctx, schedule, prefs, local)
+	}
+	for _, reminder := range schedule.Reminders {
+		s.sendReminder(ctx, schedule, prefs, local, reminder)
+	}
+}
+
+// sendSummary sends the summary of the previous day once the current day
+// has started.
+func (s *Scheduler) sendSummary(ctx context.Context, schedule *models.UserSchedule, prefs *models.UserPreferences, now time.Time) {
+	today := report.DayOf(now, prefs.Noon)
+	if now.Sub(today.Start) > summaryWindow {
+		return
+	}
+	yesterday := report.DayOf(today.Start.Add(-time.Nanosecond), prefs.Noon)
+
+	s.notify(ctx, schedule, summaryKind, yesterday.Start, func() (string, error) {
+		entries, err := s.entries(ctx, schedule.UserID, yesterday, prefs)
+		if err != nil {
+			return "", err
+		}
+		group := report.Group([]report.Day{yesterday}, entries)[0]
+		return report.DaySummary(i18n.New(i18n.Resolve(prefs.Lang, "")), group, prefs), nil
+	})
+}
+
+// sendReminder reminds the user to log a meal at the reminder time unless
+// something was logged since the previous reminder or the start of the day.
+func (s *Scheduler) sendReminder(ctx context.Context, schedule *models.UserSchedule, prefs *models.UserPreferences, now time.Time, reminder time.Time) {
+	occurrence := report.DayStart(now, reminder)
+	if now.Sub(occurrence) > reminderWindow {
+		return
+	}
+
+	since := report.DayStart(occurrence, prefs.Noon)
+	for _, other := range schedule.Reminders {
+		if other.Equal(reminder) {
+			continue
+		}
+		if previous := report.DayStart(occurrence.Add(-time.Minute), other); previous.After(since) {
+			since = previous
+		}
+	}
+
+	records, err := s.db.GetRecordsByUserAndTimeRange(ctx, schedule.UserID, since, now)
+	if err != nil {
+		log.Printf("Error getting records of %d: %v", schedule.UserID, err)
+		return
+	}
+	if len(records) > 0 {
+		return
+	}
+
+	kind := "reminder:" + reminder.Format("15:04")
+	s.notify(ctx, schedule, kind, occurrence, func() (string, error) {
+		p := i18n.New(i18n.Resolve(prefs.Lang, ""))
+		return p.Sprintf("⏰ It's %s and nothing is logged since %s. Don't forget to /record your meal!",
+			occurrence.Format("15:04"), since.Format("15:04")), nil
+	})
+}
+
+// notify claims the notification and sends the rendered message, releasing
+// the claim if sending fails so it is retried on the next tick.
+func (s *Scheduler) notify(ctx context.Context, schedule *models.UserSchedule, kind string, day time.Time, render func() (string, error)) {
+	claimed, err := s.db.ClaimNotification(ctx, schedule.UserID, kind, day)
+	if err != nil {
+		log.Printf("Error claiming %s for %d: %v", kind, schedule.UserID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	message, err := render()
+	if err == nil {
+		_, err = s.sender.Send(tele.ChatID(schedule.ChatID), message, &tele.SendOptions{ParseMode: tele.ModeHTML})
+	}
+	if err != nil {
+		log.Printf("Error sending %s to %d: %v", kind, schedule.UserID, err)
+
+		// Release even when the user's time is up, or the notification
+		// would never be sent.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), userTimeout)
+		defer cancel()
+		if err := s.db.ReleaseNotification(ctx, schedule.UserID, kind, day); err != nil {
+			log.Printf("Error releasing %s for %d: %v", kind, schedule.UserID, err)
+		}
+	}
+}
+
+func (s *Scheduler) entries(ctx context.Context, userID int64, day report.Day, prefs *models.UserPreferences) ([]report.Entry, error) {
+	records, err := s.db.GetRecordsByUserAndTimeRange(ctx, userID, day.Start, day.End)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []report.Entry
+	for _, record := range records {
+		product, err := s.db.GetProductByUUID(ctx, record.ProductUUID)
+		if err != nil {
+			log.Printf("Error getting product %s: %v", record.ProductUUID, err)
+			continue
+		}
+
+		createdAt := record.CreatedAt.In(prefs.Location())
+		meal := record.Meal
+		if meal == "" {
+			meal = models.InferMeal(createdAt, prefs.Noon)
+		}
+
+		entries = append(entries, report.Entry{
+			Time:      createdAt,
+			Name:      product.Name,
+			Meal:      meal,
+			Nutrition: record.Nutrition(),
+		})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// UserCommonItem operations
+
+func (s *Store) InsertUserCommonItem(ctx context.Context, userID int64, path, name string, productUUID *uuid.UUID) (*models.UserCommonItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.itemAt(userID, path) != nil {
+		return nil, fmt.Errorf("item %s already exists", path)
+	}
+
+	item := &models.UserCommonItem{
+		UUID:      uuid.New(),
+		UserID:    userID,
+		Path:      path,
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+	if productUUID != nil {
+		id := *productUUID
+		item.ProductUUID = &id
+	}
+	s.items[item.UUID] = item
+
+	return copyItem(item), nil
+}
+
+func (s *Store) GetUserCommonItemByUUID(ctx context.Context, userID int64, itemUUID uuid.UUID) (*models.UserCommonItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[itemUUID]
+	if !ok || item.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	return copyItem(item), nil
+}
+
+func (s *Store) GetUserCommonItemByPath(ctx context.Context, userID int64, path string) (*models.UserCommonItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item := s.itemAt(userID, path)
+	if item == nil {
+		return nil, sql.ErrNoRows
+	}
+	return copyItem(item), nil
+}
+
+func (s *Store) GetUserCommonItemsByUser(ctx context.Context, userID int64) ([]*models.UserCommonItem, error) {
+	return s.filterItems(userID, func(item *models.UserCommonItem) bool {
+		return true
+	}), nil
+}
+
+func (s *Store) GetUserCommonItemsAtLevel(ctx context.Context, userID int64, parentPath string) ([]*models.UserCommonItem, error) {
+	return s.filterItems(userID, func(item *models.UserCommonItem) bool {
+		if parentPath == "" {
+			return !strings.Contains(item.Path, ".")
+		}
+		rest, ok := strings.CutPrefix(item.Path, parentPath+".")
+		return ok && !strings.Contains(rest, ".")
+	}), nil
+}
+
+func (s *Store) SearchUserCommonItemsByName(ctx context.Context, userID int64, query string, limit int) ([]*models.UserCommonItem, error) {
+	query = strings.ToLower(query)
+	items := s.filterItems(userID, func(item *models.UserCommonItem) bool {
+		return item.ProductUUID != nil && strings.Contains(strings.ToLower(item.Name), query)
+	})
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func (s *Store) RenameUserCommonItem(ctx context.Context, userID int64, itemUUID uuid.UUID, name string) (*models.UserCommonItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[itemUUID]
+	if !ok || item.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+
+	item.Name = name
+	return copyItem(item), nil
+}
+
+func (s *Store) MoveUserCommonItemSubtree(ctx context.Context, userID int64, itemUUID uuid.UUID, newParentPath string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[itemUUID]
+	if !ok || item.UserID != userID {
+		return "", sql.ErrNoRows
+	}
+	oldPath := item.Path
+
+	if newParentPath == oldPath || strings.HasPrefix(newParentPath, oldPath+".") {
+		return "", fmt.Errorf("cannot move %s into itself", oldPath)
+	}
+
+	label := oldPath[strings.LastIndex(oldPath, ".")+1:]
+	base := label
+	if newParentPath != "" {
+		base = newParentPath + "." + label
+	}
+
+	newPath := base
+	for i := 2; s.itemAt(userID, newPath) != nil; i++ {
+		newPath = fmt.Sprintf("%s_%d", base, i)
+	}
+
+	for _, other := range s.items {
+		if other.UserID == userID && inSubtree(other.Path, oldPath) {
+			other.Path = newPath + other.Path[len(oldPath):]
+		}
+	}
+
+	return newPath, nil
+}
+
+func (s *Store) DeleteUserCommonItemSubtree(ctx context.Context, userID int64, itemUUID uuid.UUID) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[itemUUID]
+	if !ok || item.UserID != userID {
+		return 0, nil
+	}
+	root := item.Path
+
+	var deleted int64
+	for id, other := range s.items {
+		if other.UserID == userID && inSubtree(other.Path, root) {
+			delete(s.items, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func (s *Store) itemAt(userID int64, path string) *models.UserCommonItem {
+	for _, item := range s.items {
+		if item.UserID == userID && item.Path == path {
+			return item
+		}
+	}
+	return nil
+}
+
+// filterItems returns copies of the user's items that match by path.
+func (s *Store) filterItems(userID int64, match func(item *models.UserCommonItem) bool) []*models.UserCommonItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var items []*models.UserCommonItem
+	for _, item := range s.items {
+		if item.UserID == userID && match(item) {
+			items = append(items, copyItem(item))
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Path < items[j].Path
+	})
+	return items
+}
+
+// inSubtree reports whether path is root or one of its descendants, like
+// the ltree operator <@.
+func inSubtree(path, root string) bool {
+	return path == root || strings.HasPrefix(path, root+".")
+}
+
+func copyItem(item *models.UserCommonItem) *models.UserCommonItem {
+	copied := *item
+	if item.ProductUUID != nil {
+		id := *item.ProductUUID
+		copied.ProductUUID = &id
+	}
+	return &copied
+}
@@ -0,0 +1,253 @@
+// Package memory keeps the data of the bot in memory. It is meant for tests
+// and local demos: nothing survives a restart and product search only
+// matches substrings instead of ranking by similarity like PostgreSQL.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+var _ storage.Store = (*Store)(nil)
+
+// Store implements storage.Store. It is safe for concurrent use and returns
+// copies, so callers may change what they get. Nothing it does blocks, so it
+// ignores contexts.
+type Store struct {
+	mu            sync.Mutex
+	users         map[int64]string
+	products      map[uuid.UUID]*models.ProductDetails
+	recipes       map[uuid.UUID]*recipe
+	records       map[uuid.UUID]*models.Record
+	preferences   map[int64]*models.UserPreferences
+	schedules     map[int64]*models.UserSchedule
+	notifications map[notification]bool
+	items         map[uuid.UUID]*models.UserCommonItem
+}
+
+// New returns an empty store.
+func New() *Store {
+	return &Store{
+		users:         make(map[int64]string),
+		products:      make(map[uuid.UUID]*models.ProductDetails),
+		recipes:       make(map[uuid.UUID]*recipe),
+		records:       make(map[uuid.UUID]*models.Record),
+		preferences:   make(map[int64]*models.UserPreferences),
+		schedules:     make(map[int64]*models.UserSchedule),
+		notifications: make(map[notification]bool),
+		items:         make(map[uuid.UUID]*models.UserCommonItem),
+	}
+}
+
+// GetLatestSchemaVersion reports that there is no schema to migrate.
+func (s *Store) GetLatestSchemaVersion(ctx context.Context) (string, error) {
+	return "in-memory", nil
+}
+
+// User operations
+
+func (s *Store) EnsureUser(ctx context.Context, id int64, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if username != "" {
+		// Usernames are unique, so anyone else who had it has changed theirs
+		for other, name := range s.users {
+			if other != id && strings.EqualFold(name, username) {
+				s.users[other] = ""
+			}
+		}
+	}
+
+	s.users[id] = username
+	return nil
+}
+
+func (s *Store) GetUserIDByUsername(ctx context.Context, username string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, name := range s.users {
+		if name != "" && strings.EqualFold(name, username) {
+			return id, nil
+		}
+	}
+	return 0, sql.ErrNoRows
+}
+
+func (s *Store) GetUsername(ctx context.Context, id int64) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	username, ok := s.users[id]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return username, nil
+}
+
+// UserPreferences operations
+
+func (s *Store) GetUserPreferencesOrDefault(ctx context.Context, userID int64) (*models.UserPreferences, error) {
+	prefs, err := s.GetUserPreferences(ctx, userID)
+	if err == sql.ErrNoRows {
+		return models.DefaultUserPreferences(userID), nil
+	}
+	return prefs, err
+}
+
+func (s *Store) GetUserPreferences(ctx context.Context, userID int64) (*models.UserPreferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs, ok := s.preferences[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	copied := *prefs
+	return &copied, nil
+}
+
+func (s *Store) UpsertUserNoon(ctx context.Context, userID int64, noon time.Time) error {
+	s.updatePreferences(userID, func(prefs *models.UserPreferences) {
+		prefs.Noon = noon
+	})
+	return nil
+}
+
+func (s *Store) UpsertUserLang(ctx context.Context, userID int64, lang string) error {
+	s.updatePreferences(userID, func(prefs *models.UserPreferences) {
+		prefs.Lang = lang
+	})
+	return nil
+}
+
+func (s *Store) UpsertUserTimezone(ctx context.Context, userID int64, timezone string) error {
+	s.updatePreferences(userID, func(prefs *models.UserPreferences) {
+		prefs.Timezone = timezone
+	})
+	return nil
+}
+
+func (s *Store) UpsertUserGoals(ctx context.Context, userID int64, kcal, proteins, fats, carbs int64) error {
+	s.updatePreferences(userID, func(prefs *models.UserPreferences) {
+		prefs.GoalKcal = kcal
+		prefs.GoalProteins = proteins
+		prefs.GoalFats = fats
+		prefs.GoalCarbs = carbs
+	})
+	return nil
+}
+
+// updatePreferences applies update to the user's preferences, starting from
+// the defaults if they have none yet.
+func (s *Store) updatePreferences(userID int64, update func(prefs *models.UserPreferences)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefs, ok := s.preferences[userID]
+	if !ok {
+		prefs = models.DefaultUserPreferences(userID)
+		s.preferences[userID] = prefs
+	}
+	update(prefs)
+}
+
+// UserSchedule operations
+
+type notification struct {
+	userID int64
+	kind   string
+	day    string
+}
+
+func (s *Store) GetUserSchedule(ctx context.Context, userID int64) (*models.UserSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copySchedule(schedule), nil
+}
+
+func (s *Store) GetUserSchedules(ctx context.Context) ([]*models.UserSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var schedules []*models.UserSchedule
+	for _, schedule := range s.schedules {
+		if schedule.DailySummary || len(schedule.Reminders) > 0 {
+			schedules = append(schedules, copySchedule(schedule))
+		}
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].UserID < schedules[j].UserID
+	})
+	return schedules, nil
+}
+
+func (s *Store) UpsertUserDailySummary(ctx context.Context, userID int64, chatID int64, enabled bool) error {
+	s.updateSchedule(userID, chatID, func(schedule *models.UserSchedule) {
+		schedule.DailySummary = enabled
+	})
+	return nil
+}
+
+func (s *Store) UpsertUserReminders(ctx context.Context, userID int64, chatID int64, reminders []time.Time) error {
+	s.updateSchedule(userID, chatID, func(schedule *models.UserSchedule) {
+		schedule.Reminders = append([]time.Time(nil), reminders...)
+	})
+	return nil
+}
+
+func (s *Store) updateSchedule(userID, chatID int64, update func(schedule *models.UserSchedule)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schedule, ok := s.schedules[userID]
+	if !ok {
+		schedule = &models.UserSchedule{UserID: userID}
+		s.schedules[userID] = schedule
+	}
+	schedule.ChatID = chatID
+	update(schedule)
+}
+
+func (s *Store) ClaimNotification(ctx context.Context, userID int64, kind string, day time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := notification{userID: userID, kind: kind, day: day.Format("2006-01-02")}
+	if s.notifications[key] {
+		return false, nil
+	}
+	s.notifications[key] = true
+	return true, nil
+}
+
+func (s *Store) ReleaseNotification(ctx context.Context, userID int64, kind string, day time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.notifications, notification{userID: userID, kind: kind, day: day.Format("2006-01-02")})
+	return nil
+}
+
+func copySchedule(schedule *models.UserSchedule) *models.UserSchedule {
+	copied := *schedule
+	copied.Reminders = append([]time.Time(nil), schedule.Reminders...)
+	return &copied
+}
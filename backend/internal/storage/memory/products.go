@@ -0,0 +1,410 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ProductDetails operations
+
+func (s *Store) InsertProduct(ctx context.Context, name string, ccal, fats, proteins, carbs int64, unit string, owner *int64) (*models.ProductDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product := s.insertProduct(s.products, &models.ProductDetails{
+		Name:     name,
+		Ccal:     ccal,
+		Fats:     fats,
+		Proteins: proteins,
+		Carbs:    carbs,
+		Unit:     unit,
+		Owner:    owner,
+	})
+	return copyProduct(product), nil
+}
+
+func (s *Store) GetProductByUUID(ctx context.Context, productUUID uuid.UUID) (*models.ProductDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.products[productUUID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copyProduct(product), nil
+}
+
+func (s *Store) GetProductByName(ctx context.Context, userID int64, name string) (*models.ProductDetails, error) {
+	return s.findProduct(userID, func(product *models.ProductDetails) bool {
+		return strings.EqualFold(product.Name, name)
+	})
+}
+
+func (s *Store) SearchProductsByName(ctx context.Context, userID int64, query string, limit int) ([]*models.ProductDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query = strings.ToLower(query)
+	var products []*models.ProductDetails
+	for _, product := range s.products {
+		if visible(product, userID) && strings.Contains(strings.ToLower(product.Name), query) {
+			products = append(products, product)
+		}
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		iExact := strings.ToLower(products[i].Name) == query
+		jExact := strings.ToLower(products[j].Name) == query
+		if iExact != jExact {
+			return iExact
+		}
+		return ownFirst(products[i], products[j])
+	})
+
+	if len(products) > limit {
+		products = products[:limit]
+	}
+	return copyProducts(products), nil
+}
+
+func (s *Store) GetProductByBarcode(ctx context.Context, userID int64, barcodes []string) (*models.ProductDetails, error) {
+	return s.findProduct(userID, func(product *models.ProductDetails) bool {
+		for _, barcode := range barcodes {
+			if product.Barcode != "" && product.Barcode == barcode {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func (s *Store) GetOrCreateProduct(ctx context.Context, userID int64, name string, ccal, fats, proteins, carbs int64, unit string) (*models.ProductDetails, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, product := range s.products {
+		if owned(product, userID) && strings.EqualFold(product.Name, name) {
+			return copyProduct(product), false, nil
+		}
+	}
+
+	product := s.insertProduct(s.products, &models.ProductDetails{
+		Name:     name,
+		Ccal:     ccal,
+		Fats:     fats,
+		Proteins: proteins,
+		Carbs:    carbs,
+		Unit:     unit,
+		Owner:    &userID,
+	})
+	return copyProduct(product), true, nil
+}
+
+func (s *Store) UpdateProduct(ctx context.Context, userID int64, productUUID uuid.UUID, name string, ccal, fats, proteins, carbs int64, unit string) (*models.ProductDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.products[productUUID]
+	if !ok || !owned(product, userID) {
+		return nil, sql.ErrNoRows
+	}
+
+	product.Name, product.Unit = name, unit
+	product.Ccal, product.Fats, product.Proteins, product.Carbs = ccal, fats, proteins, carbs
+	return copyProduct(product), nil
+}
+
+func (s *Store) UpsertSharedProducts(ctx context.Context, products []*models.ProductDetails) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range products {
+		var existing *models.ProductDetails
+		for _, product := range s.products {
+			if product.Owner == nil && product.Barcode == p.Barcode {
+				existing = product
+				break
+			}
+		}
+
+		if existing == nil {
+			existing = s.insertProduct(s.products, &models.ProductDetails{Barcode: p.Barcode})
+		}
+		existing.Name, existing.Unit, existing.Brand = p.Name, p.Unit, p.Brand
+		existing.Ccal, existing.Fats, existing.Proteins, existing.Carbs = p.Ccal, p.Fats, p.Proteins, p.Carbs
+	}
+
+	return nil
+}
+
+func (s *Store) UpsertProducts(ctx context.Context, owner *int64, products []*models.ProductDetails) (int, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Work on copies so a failing product leaves the catalog unchanged
+	catalog := make(map[uuid.UUID]*models.ProductDetails, len(s.products))
+	for id, product := range s.products {
+		catalog[id] = copyProduct(product)
+	}
+
+	created, updated := 0, 0
+	for _, p := range products {
+		var byBarcode, byName *models.ProductDetails
+		for _, product := range catalog {
+			if !sameOwner(product.Owner, owner) {
+				continue
+			}
+			if p.Barcode != "" && product.Barcode == p.Barcode {
+				byBarcode = product
+			} else if strings.EqualFold(product.Name, p.Name) {
+				byName = product
+			}
+		}
+
+		existing := byBarcode
+		if existing == nil {
+			existing = byName
+		}
+
+		switch {
+		case existing == nil:
+			existing = s.insertProduct(catalog, &models.ProductDetails{Owner: owner})
+			created++
+		case s.recipes[existing.UUID] != nil:
+			return 0, 0, fmt.Errorf("product %s: %s is a recipe, its nutrition comes from the ingredients", p.Name, p.Name)
+		default:
+			updated++
+		}
+		existing.Name, existing.Unit, existing.Brand, existing.Barcode = p.Name, p.Unit, p.Brand, p.Barcode
+		existing.Ccal, existing.Fats, existing.Proteins, existing.Carbs = p.Ccal, p.Fats, p.Proteins, p.Carbs
+	}
+
+	s.products = catalog
+	return created, updated, nil
+}
+
+func (s *Store) EachProduct(ctx context.Context, owner *int64, fn func(*models.ProductDetails) error) error {
+	s.mu.Lock()
+	var products []*models.ProductDetails
+	for _, product := range s.products {
+		if sameOwner(product.Owner, owner) {
+			products = append(products, copyProduct(product))
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(products, func(i, j int) bool {
+		iName, jName := strings.ToLower(products[i].Name), strings.ToLower(products[j].Name)
+		if iName != jName {
+			return iName < jName
+		}
+		return products[i].UUID.String() < products[j].UUID.String()
+	})
+
+	for _, product := range products {
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertProduct adds product to catalog under a new UUID and returns it.
+func (s *Store) insertProduct(catalog map[uuid.UUID]*models.ProductDetails, product *models.ProductDetails) *models.ProductDetails {
+	product.UUID = uuid.New()
+	if product.Owner != nil {
+		owner := *product.Owner
+		product.Owner = &owner
+	}
+	catalog[product.UUID] = product
+	return product
+}
+
+// findProduct returns the first product visible to the user that matches,
+// the user's own products first.
+func (s *Store) findProduct(userID int64, match func(product *models.ProductDetails) bool) (*models.ProductDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found *models.ProductDetails
+	for _, product := range s.products {
+		if visible(product, userID) && match(product) && (found == nil || ownFirst(product, found)) {
+			found = product
+		}
+	}
+
+	if found == nil {
+		return nil, sql.ErrNoRows
+	}
+	return copyProduct(found), nil
+}
+
+// Recipe operations
+
+type recipe struct {
+	cookedWeight float64
+	ingredients  []ingredient
+}
+
+type ingredient struct {
+	productUUID uuid.UUID
+	amount      float64
+}
+
+func newRecipe(r *models.Recipe) *recipe {
+	stored := &recipe{cookedWeight: r.CookedWeight}
+	for _, i := range r.Ingredients {
+		stored.ingredients = append(stored.ingredients, ingredient{productUUID: i.Product.UUID, amount: i.Amount})
+	}
+	return stored
+}
+
+func (s *Store) CreateRecipe(ctx context.Context, userID int64, r *models.Recipe) (*models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dish := r.Product
+	product := s.insertProduct(s.products, &models.ProductDetails{
+		Name:     dish.Name,
+		Ccal:     dish.Ccal,
+		Fats:     dish.Fats,
+		Proteins: dish.Proteins,
+		Carbs:    dish.Carbs,
+		Unit:     dish.Unit,
+		Owner:    &userID,
+	})
+	s.recipes[product.UUID] = newRecipe(r)
+
+	return &models.Recipe{
+		Product:      copyProduct(product),
+		CookedWeight: r.CookedWeight,
+		Ingredients:  r.Ingredients,
+	}, nil
+}
+
+func (s *Store) UpdateRecipe(ctx context.Context, userID int64, r *models.Recipe) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dish := r.Product
+	product, ok := s.products[dish.UUID]
+	if !ok || !owned(product, userID) || s.recipes[dish.UUID] == nil {
+		return sql.ErrNoRows
+	}
+
+	product.Name, product.Unit = dish.Name, dish.Unit
+	product.Ccal, product.Fats, product.Proteins, product.Carbs = dish.Ccal, dish.Fats, dish.Proteins, dish.Carbs
+	s.recipes[dish.UUID] = newRecipe(r)
+	return nil
+}
+
+func (s *Store) GetRecipe(ctx context.Context, userID int64, productUUID uuid.UUID) (*models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.products[productUUID]
+	if !ok || !owned(product, userID) || s.recipes[productUUID] == nil {
+		return nil, sql.ErrNoRows
+	}
+	return s.loadRecipe(product), nil
+}
+
+func (s *Store) GetRecipeByName(ctx context.Context, userID int64, name string) (*models.Recipe, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, product := range s.products {
+		if owned(product, userID) && s.recipes[product.UUID] != nil && strings.EqualFold(product.Name, name) {
+			return s.loadRecipe(product), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *Store) loadRecipe(product *models.ProductDetails) *models.Recipe {
+	stored := s.recipes[product.UUID]
+	r := &models.Recipe{Product: copyProduct(product), CookedWeight: stored.cookedWeight}
+	for _, i := range stored.ingredients {
+		if ingredientProduct, ok := s.products[i.productUUID]; ok {
+			r.Ingredients = append(r.Ingredients, models.RecipeIngredient{Product: copyProduct(ingredientProduct), Amount: i.amount})
+		}
+	}
+	return r
+}
+
+func (s *Store) GetRecipesByUser(ctx context.Context, userID int64) ([]*models.ProductDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var dishes []*models.ProductDetails
+	for _, product := range s.products {
+		if owned(product, userID) && s.recipes[product.UUID] != nil {
+			dishes = append(dishes, product)
+		}
+	}
+
+	sort.Slice(dishes, func(i, j int) bool {
+		return strings.ToLower(dishes[i].Name) < strings.ToLower(dishes[j].Name)
+	})
+	return copyProducts(dishes), nil
+}
+
+func (s *Store) DeleteRecipe(ctx context.Context, userID int64, productUUID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.products[productUUID]
+	if !ok || !owned(product, userID) || s.recipes[productUUID] == nil {
+		return sql.ErrNoRows
+	}
+
+	delete(s.recipes, productUUID)
+	return nil
+}
+
+func visible(product *models.ProductDetails, userID int64) bool {
+	return product.Owner == nil || *product.Owner == userID
+}
+
+func owned(product *models.ProductDetails, userID int64) bool {
+	return product.Owner != nil && *product.Owner == userID
+}
+
+func sameOwner(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// ownFirst orders the user's own products before shared ones, then by name.
+func ownFirst(a, b *models.ProductDetails) bool {
+	if (a.Owner == nil) != (b.Owner == nil) {
+		return a.Owner != nil
+	}
+	return a.Name < b.Name
+}
+
+func copyProduct(product *models.ProductDetails) *models.ProductDetails {
+	copied := *product
+	if product.Owner != nil {
+		owner := *product.Owner
+		copied.Owner = &owner
+	}
+	return &copied
+}
+
+func copyProducts(products []*models.ProductDetails) []*models.ProductDetails {
+	copies := make([]*models.ProductDetails, len(products))
+	for i, product := range products {
+		copies[i] = copyProduct(product)
+	}
+	return copies
+}
@@ -0,0 +1,145 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Record operations
+
+func (s *Store) InsertRecord(ctx context.Context, productUUID uuid.UUID, amount float64, userID int64, meal string) (*models.Record, error) {
+	return s.InsertRecordAt(ctx, productUUID, amount, userID, meal, time.Now())
+}
+
+func (s *Store) InsertRecordAt(ctx context.Context, productUUID uuid.UUID, amount float64, userID int64, meal string, createdAt time.Time) (*models.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, ok := s.products[productUUID]
+	if !ok {
+		return nil, fmt.Errorf("unknown product %s", productUUID)
+	}
+
+	record := &models.Record{
+		UUID:        uuid.New(),
+		ProductUUID: productUUID,
+		Amount:      amount,
+		UserID:      userID,
+		Meal:        meal,
+		CreatedAt:   createdAt,
+		Ccal:        product.Ccal,
+		Fats:        product.Fats,
+		Proteins:    product.Proteins,
+		Carbs:       product.Carbs,
+		Unit:        product.Unit,
+	}
+	s.records[record.UUID] = record
+
+	copied := *record
+	return &copied, nil
+}
+
+func (s *Store) GetRecordByUUID(ctx context.Context, recordUUID uuid.UUID) (*models.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[recordUUID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	copied := *record
+	return &copied, nil
+}
+
+func (s *Store) GetRecordsByUserAndTimeRange(ctx context.Context, userID int64, startTime, endTime time.Time) ([]*models.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []*models.Record
+	for _, record := range s.records {
+		if record.UserID == userID && !record.CreatedAt.Before(startTime) && !record.CreatedAt.After(endTime) {
+			copied := *record
+			records = append(records, &copied)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+	return records, nil
+}
+
+func (s *Store) UpdateRecordAmount(ctx context.Context, userID int64, recordUUID uuid.UUID, amount float64) (*models.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[recordUUID]
+	if !ok || record.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+
+	record.Amount = amount
+	copied := *record
+	return &copied, nil
+}
+
+func (s *Store) DeleteRecord(ctx context.Context, userID int64, recordUUID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[recordUUID]
+	if !ok || record.UserID != userID {
+		return sql.ErrNoRows
+	}
+
+	delete(s.records, recordUUID)
+	return nil
+}
+
+func (s *Store) GetDailyTotals(ctx context.Context, userID int64, startTime, endTime time.Time, timezone string, noon time.Time) ([]*models.DailyTotal, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	flip := time.Duration(noon.Hour())*time.Hour + time.Duration(noon.Minute())*time.Minute + time.Duration(noon.Second())*time.Second
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byDay := make(map[time.Time]*models.DailyTotal)
+	for _, record := range s.records {
+		if record.UserID != userID || record.CreatedAt.Before(startTime) || !record.CreatedAt.Before(endTime) {
+			continue
+		}
+		// Shift the wall clock, as PostgreSQL does with local timestamps
+		local := record.CreatedAt.In(loc)
+		shifted := time.Date(local.Year(), local.Month(), local.Day(),
+			local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), time.UTC).Add(-flip)
+		day := time.Date(shifted.Year(), shifted.Month(), shifted.Day(), 0, 0, 0, 0, time.UTC)
+
+		total, ok := byDay[day]
+		if !ok {
+			total = &models.DailyTotal{Day: day}
+			byDay[day] = total
+		}
+		total.Nutrition = total.Nutrition.Add(record.Nutrition())
+		total.Records++
+	}
+
+	totals := make([]*models.DailyTotal, 0, len(byDay))
+	for _, total := range byDay {
+		totals = append(totals, total)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		return totals[i].Day.Before(totals[j].Day)
+	})
+	return totals, nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// RefreshRecipes recomputes the nutrition of the user's dishes from the
+// current nutrition of their ingredients, which may have changed since the
+// recipes were saved. Dishes made of other dishes see their refreshed
+// nutrition. Only dishes whose nutrition changed are stored; records of
+// them keep the nutrition they were recorded with.
+func RefreshRecipes(ctx context.Context, store Recipes, userID int64) error {
+	dishes, err := store.GetRecipesByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	recipes := make(map[uuid.UUID]*models.Recipe, len(dishes))
+	for _, dish := range dishes {
+		recipe, err := store.GetRecipe(ctx, userID, dish.UUID)
+		if err != nil {
+			return err
+		}
+		recipes[dish.UUID] = recipe
+	}
+
+	// Share the dish products, so that a recomputed dish is seen by the
+	// recipes made of it.
+	for _, recipe := range recipes {
+		for i, ingredient := range recipe.Ingredients {
+			if dish, ok := recipes[ingredient.Product.UUID]; ok {
+				recipe.Ingredients[i].Product = dish.Product
+			}
+		}
+	}
+
+	// Every round settles at least one more level of nesting.
+	for range recipes {
+		changed := false
+		for _, recipe := range recipes {
+			before := *recipe.Product
+			recipe.Compute()
+			dish := recipe.Product
+			if dish.Ccal == before.Ccal && dish.Proteins == before.Proteins &&
+				dish.Fats == before.Fats && dish.Carbs == before.Carbs && dish.Unit == before.Unit {
+				continue
+			}
+
+			if err := store.UpdateRecipe(ctx, userID, recipe); err != nil {
+				return err
+			}
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return nil
+}
+
+// LeadsTo reports whether the product is the dish or is made of it, directly
+// or through other recipes, so that adding it to the dish would make a cycle.
+func LeadsTo(ctx context.Context, store Recipes, userID int64, productUUID, dishUUID uuid.UUID) (bool, error) {
+	seen := make(map[uuid.UUID]bool)
+	queue := []uuid.UUID{productUUID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == dishUUID {
+			return true, nil
+		}
+		if seen[current] {
+			continue
+		}
+		seen[current] = true
+
+		recipe, err := store.GetRecipe(ctx, userID, current)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		for _, ingredient := range recipe.Ingredients {
+			queue = append(queue, ingredient.Product.UUID)
+		}
+	}
+	return false, nil
+}
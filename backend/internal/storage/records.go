@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// UserPreferences returns the user's preferences, or the defaults if they
+// cannot be loaded, so that handlers can carry on with sensible settings.
+func UserPreferences(ctx context.Context, store Preferences, userID int64) *models.UserPreferences {
+	prefs, err := store.GetUserPreferencesOrDefault(ctx, userID)
+	if err != nil {
+		log.Printf("Error getting preferences: %v", err)
+		return models.DefaultUserPreferences(userID)
+	}
+	return prefs
+}
+
+// RecordStore is what InsertRecord needs.
+type RecordStore interface {
+	Preferences
+	Records
+}
+
+// InsertRecord stores a record of the user eaten at when, or now for a zero
+// time. An empty meal is inferred from that time in the user's timezone.
+func InsertRecord(ctx context.Context, store RecordStore, userID int64, productUUID uuid.UUID, amount float64, meal string, when time.Time) (*models.Record, error) {
+	if meal == "" {
+		prefs := UserPreferences(ctx, store, userID)
+		at := when
+		if at.IsZero() {
+			at = time.Now()
+		}
+		meal = models.InferMeal(at.In(prefs.Location()), prefs.Noon)
+	}
+
+	if when.IsZero() {
+		return store.InsertRecord(ctx, productUUID, amount, userID, meal)
+	}
+	return store.InsertRecordAt(ctx, productUUID, amount, userID, meal, when)
+}
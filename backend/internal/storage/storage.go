@@ -0,0 +1,158 @@
+// Package storage defines the repositories the bot keeps its data in, so
+// handlers do not depend on PostgreSQL. database.DB implements them on top
+// of PostgreSQL and memory.Store keeps everything in memory for tests and
+// local demos.
+//
+// Users are identified by their Telegram ID. Lookups of a missing or foreign
+// row return sql.ErrNoRows, whatever the implementation. Every operation
+// takes a context and gives up once it is done.
+package storage
+
+import (
+	"context"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Users are the people who have written to the bot.
+type Users interface {
+	// EnsureUser records the user id with their current username, which is
+	// empty for users without one.
+	EnsureUser(ctx context.Context, id int64, username string) error
+	// GetUserIDByUsername finds a user by their current username, ignoring
+	// case.
+	GetUserIDByUsername(ctx context.Context, username string) (int64, error)
+	// GetUsername returns the current username of a known user, which is
+	// empty for users without one.
+	GetUsername(ctx context.Context, id int64) (string, error)
+}
+
+// Products is the product catalog. A nil owner stands for the shared
+// catalog.
+type Products interface {
+	InsertProduct(ctx context.Context, name string, ccal, fats, proteins, carbs int64, unit string, owner *int64) (*models.ProductDetails, error)
+	GetProductByUUID(ctx context.Context, productUUID uuid.UUID) (*models.ProductDetails, error)
+	// GetProductByName finds a product visible to the user by its exact
+	// name, ignoring case. Their own products come before shared ones.
+	GetProductByName(ctx context.Context, userID int64, name string) (*models.ProductDetails, error)
+	// SearchProductsByName returns products visible to the user that match
+	// query, best matches first.
+	SearchProductsByName(ctx context.Context, userID int64, query string, limit int) ([]*models.ProductDetails, error)
+	// GetProductByBarcode finds a product visible to the user by any of the
+	// given barcode spellings.
+	GetProductByBarcode(ctx context.Context, userID int64, barcodes []string) (*models.ProductDetails, error)
+	// GetOrCreateProduct returns the user's own product named name, creating
+	// it with the given nutrition if they have none. It reports whether the
+	// product was created.
+	GetOrCreateProduct(ctx context.Context, userID int64, name string, ccal, fats, proteins, carbs int64, unit string) (*models.ProductDetails, bool, error)
+	// UpdateProduct changes a product owned by the user.
+	UpdateProduct(ctx context.Context, userID int64, productUUID uuid.UUID, name string, ccal, fats, proteins, carbs int64, unit string) (*models.ProductDetails, error)
+	// UpsertSharedProducts stores products in the shared catalog, updating
+	// the ones already known by barcode.
+	UpsertSharedProducts(ctx context.Context, products []*models.ProductDetails) error
+	// UpsertProducts stores products in a catalog all at once, replacing
+	// the ones with the same barcode or name. It returns the number of
+	// created and updated products.
+	UpsertProducts(ctx context.Context, owner *int64, products []*models.ProductDetails) (int, int, error)
+	// EachProduct calls fn with every product of a catalog by name.
+	EachProduct(ctx context.Context, owner *int64, fn func(*models.ProductDetails) error) error
+}
+
+// Recipes are dishes of their owner's catalog made of other products.
+type Recipes interface {
+	CreateRecipe(ctx context.Context, userID int64, recipe *models.Recipe) (*models.Recipe, error)
+	UpdateRecipe(ctx context.Context, userID int64, recipe *models.Recipe) error
+	GetRecipe(ctx context.Context, userID int64, productUUID uuid.UUID) (*models.Recipe, error)
+	GetRecipeByName(ctx context.Context, userID int64, name string) (*models.Recipe, error)
+	// GetRecipesByUser returns the dishes of all recipes of the user by
+	// name.
+	GetRecipesByUser(ctx context.Context, userID int64) ([]*models.ProductDetails, error)
+	// DeleteRecipe turns a dish of the user back into a plain product.
+	DeleteRecipe(ctx context.Context, userID int64, productUUID uuid.UUID) error
+}
+
+// Records are the amounts of products users have eaten.
+type Records interface {
+	// InsertRecord stores a record eaten now. An empty meal is left to be
+	// inferred from the record's time.
+	InsertRecord(ctx context.Context, productUUID uuid.UUID, amount float64, userID int64, meal string) (*models.Record, error)
+	// InsertRecordAt stores a record eaten at createdAt.
+	InsertRecordAt(ctx context.Context, productUUID uuid.UUID, amount float64, userID int64, meal string, createdAt time.Time) (*models.Record, error)
+	GetRecordByUUID(ctx context.Context, recordUUID uuid.UUID) (*models.Record, error)
+	// GetRecordsByUserAndTimeRange returns the user's records between
+	// startTime and endTime inclusive, latest first.
+	GetRecordsByUserAndTimeRange(ctx context.Context, userID int64, startTime, endTime time.Time) ([]*models.Record, error)
+	UpdateRecordAmount(ctx context.Context, userID int64, recordUUID uuid.UUID, amount float64) (*models.Record, error)
+	DeleteRecord(ctx context.Context, userID int64, recordUUID uuid.UUID) error
+	// GetDailyTotals sums the nutrition of the user's records from startTime
+	// up to endTime per day. Days start at the day-flip time noon in
+	// timezone and are identified by their starting date.
+	GetDailyTotals(ctx context.Context, userID int64, startTime, endTime time.Time, timezone string, noon time.Time) ([]*models.DailyTotal, error)
+}
+
+// Preferences are per-user settings.
+type Preferences interface {
+	// GetUserPreferencesOrDefault returns the user's preferences, or the
+	// defaults if they have never set any.
+	GetUserPreferencesOrDefault(ctx context.Context, userID int64) (*models.UserPreferences, error)
+	GetUserPreferences(ctx context.Context, userID int64) (*models.UserPreferences, error)
+	UpsertUserNoon(ctx context.Context, userID int64, noon time.Time) error
+	UpsertUserLang(ctx context.Context, userID int64, lang string) error
+	UpsertUserTimezone(ctx context.Context, userID int64, timezone string) error
+	UpsertUserGoals(ctx context.Context, userID int64, kcal, proteins, fats, carbs int64) error
+}
+
+// Schedules are the notification settings of users and the notifications
+// already sent.
+type Schedules interface {
+	GetUserSchedule(ctx context.Context, userID int64) (*models.UserSchedule, error)
+	// GetUserSchedules returns the schedules with anything to send.
+	GetUserSchedules(ctx context.Context) ([]*models.UserSchedule, error)
+	UpsertUserDailySummary(ctx context.Context, userID int64, chatID int64, enabled bool) error
+	UpsertUserReminders(ctx context.Context, userID int64, chatID int64, reminders []time.Time) error
+	// ClaimNotification marks a notification of kind for day as sent. It
+	// returns false if it has already been claimed.
+	ClaimNotification(ctx context.Context, userID int64, kind string, day time.Time) (bool, error)
+	// ReleaseNotification undoes ClaimNotification after a failed send.
+	ReleaseNotification(ctx context.Context, userID int64, kind string, day time.Time) error
+}
+
+// CommonItems is the tree of items a user eats often. Paths are dot
+// separated labels, as in ltree.
+type CommonItems interface {
+	InsertUserCommonItem(ctx context.Context, userID int64, path, name string, productUUID *uuid.UUID) (*models.UserCommonItem, error)
+	GetUserCommonItemByUUID(ctx context.Context, userID int64, itemUUID uuid.UUID) (*models.UserCommonItem, error)
+	GetUserCommonItemByPath(ctx context.Context, userID int64, path string) (*models.UserCommonItem, error)
+	// GetUserCommonItemsByUser returns all items of the user by path.
+	GetUserCommonItemsByUser(ctx context.Context, userID int64) ([]*models.UserCommonItem, error)
+	// GetUserCommonItemsAtLevel returns the children of parentPath, or the
+	// top-level items for an empty parentPath, by path.
+	GetUserCommonItemsAtLevel(ctx context.Context, userID int64, parentPath string) ([]*models.UserCommonItem, error)
+	// SearchUserCommonItemsByName returns the user's items linked to a
+	// product whose name contains query.
+	SearchUserCommonItemsByName(ctx context.Context, userID int64, query string, limit int) ([]*models.UserCommonItem, error)
+	RenameUserCommonItem(ctx context.Context, userID int64, itemUUID uuid.UUID, name string) (*models.UserCommonItem, error)
+	// MoveUserCommonItemSubtree moves an item with its descendants under
+	// newParentPath and returns its new path.
+	MoveUserCommonItemSubtree(ctx context.Context, userID int64, itemUUID uuid.UUID, newParentPath string) (string, error)
+	// DeleteUserCommonItemSubtree removes an item with its descendants and
+	// returns the number of deleted items.
+	DeleteUserCommonItemSubtree(ctx context.Context, userID int64, itemUUID uuid.UUID) (int64, error)
+}
+
+// Store holds all data of the bot.
+type Store interface {
+	Users
+	Products
+	Recipes
+	Records
+	Preferences
+	Schedules
+	CommonItems
+
+	// GetLatestSchemaVersion describes the version of the storage schema.
+	GetLatestSchemaVersion(ctx context.Context) (string, error)
+}
@@ -0,0 +1,38 @@
+// Package timeout bounds how long the bot may work on an update. Every
+// update gets its own context with a deadline that handlers pass on to the
+// storage, so a slow or unreachable database fails the update instead of
+// blocking the handler forever.
+package timeout
+
+import (
+	"context"
+	"time"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+const contextKey = "timeout.context"
+
+// Middleware gives every update a context that is done after d or once
+// the handler returns. It is available through From and should run before
+// any middleware that uses the storage.
+func Middleware(d time.Duration) tele.MiddlewareFunc {
+	return func(next tele.HandlerFunc) tele.HandlerFunc {
+		return func(c tele.Context) error {
+			ctx, cancel := context.WithTimeout(context.Background(), d)
+			defer cancel()
+
+			c.Set(contextKey, ctx)
+			return next(c)
+		}
+	}
+}
+
+// From returns the context of the update, or a background context for
+// updates that did not pass Middleware.
+func From(c tele.Context) context.Context {
+	if ctx, ok := c.Get(contextKey).(context.Context); ok {
+		return ctx
+	}
+	return context.Background()
+}
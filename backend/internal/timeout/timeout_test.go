@@ -0,0 +1,57 @@
+package timeout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/internal/bot/bottest"
+
+	tele "gopkg.in/telebot.v3"
+)
+
+func TestMiddleware(t *testing.T) {
+	c := bottest.NewMessage(&tele.User{ID: 42}, "/get")
+
+	var ctx context.Context
+	handler := Middleware(time.Minute)(func(c tele.Context) error {
+		ctx = From(c)
+
+		deadline, ok := ctx.Deadline()
+		if !ok || time.Until(deadline) > time.Minute {
+			t.Errorf("deadline = %v, %v; want within a minute", deadline, ok)
+		}
+		if err := ctx.Err(); err != nil {
+			t.Errorf("context is done while handling the update: %v", err)
+		}
+		return nil
+	})
+
+	if err := handler(c); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.Err() != context.Canceled {
+		t.Errorf("context error after the handler returned = %v, want %v", ctx.Err(), context.Canceled)
+	}
+}
+
+func TestMiddlewareDeadline(t *testing.T) {
+	c := bottest.NewMessage(&tele.User{ID: 42}, "/get")
+
+	handler := Middleware(time.Millisecond)(func(c tele.Context) error {
+		<-From(c).Done()
+		return From(c).Err()
+	})
+
+	if err := handler(c); err != context.DeadlineExceeded {
+		t.Errorf("handler error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestFromWithoutMiddleware(t *testing.T) {
+	c := bottest.NewMessage(&tele.User{ID: 42}, "/get")
+
+	if ctx := From(c); ctx != context.Background() {
+		t.Errorf("From = %v, want the background context", ctx)
+	}
+}
@@ -0,0 +1,9 @@
+-- Rollback per-user timezone
+
+ALTER TABLE user_common_items
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE records
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS timezone;
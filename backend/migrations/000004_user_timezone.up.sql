@@ -0,0 +1,11 @@
+-- Per-user timezone and timezone-aware timestamps
+
+ALTER TABLE user_preferences
+    ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'Europe/Moscow';
+
+-- Existing naive timestamps were written in Moscow time (see 000001)
+ALTER TABLE records
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'Europe/Moscow';
+
+ALTER TABLE user_common_items
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'Europe/Moscow';
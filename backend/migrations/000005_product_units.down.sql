@@ -0,0 +1,6 @@
+-- Rollback product units
+
+ALTER TABLE records
+    ALTER COLUMN amount TYPE BIGINT USING GREATEST(ROUND(amount), 1);
+
+ALTER TABLE product_details DROP COLUMN IF EXISTS unit;
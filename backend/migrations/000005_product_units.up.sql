@@ -0,0 +1,10 @@
+-- Units for product nutrition and fractional record amounts
+--
+-- Nutrition of 'g' and 'ml' products is given per 100 g/ml, of 'pcs'
+-- products per piece. Existing products were recorded as single portions.
+
+ALTER TABLE product_details
+    ADD COLUMN unit VARCHAR(8) NOT NULL DEFAULT 'pcs' CHECK (unit IN ('g', 'ml', 'pcs'));
+
+ALTER TABLE records
+    ALTER COLUMN amount TYPE NUMERIC(12, 3);
@@ -0,0 +1,6 @@
+-- Rollback product catalog
+
+DROP INDEX IF EXISTS idx_product_details_lower_name;
+DROP INDEX IF EXISTS idx_product_details_owner;
+
+ALTER TABLE product_details DROP COLUMN IF EXISTS owner;
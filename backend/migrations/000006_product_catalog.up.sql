@@ -0,0 +1,15 @@
+-- Reusable product catalog
+--
+-- Products with an owner belong to that user, products without one are
+-- shared between everyone. Existing products were created by /record, so
+-- they belong to the user who recorded them.
+
+ALTER TABLE product_details ADD COLUMN owner VARCHAR(255);
+
+UPDATE product_details p
+SET owner = r.login
+FROM records r
+WHERE r.product_uuid = p.uuid;
+
+CREATE INDEX idx_product_details_owner ON product_details(owner);
+CREATE INDEX idx_product_details_lower_name ON product_details(LOWER(name));
@@ -0,0 +1,5 @@
+-- Rollback fuzzy product search
+
+DROP INDEX IF EXISTS idx_product_details_name_trgm;
+
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,5 @@
+-- Fuzzy product search
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_product_details_name_trgm ON product_details USING GIN (name gin_trgm_ops);
@@ -0,0 +1,7 @@
+-- Rollback daily goals
+
+ALTER TABLE user_preferences
+    DROP COLUMN IF EXISTS goal_kcal,
+    DROP COLUMN IF EXISTS goal_proteins,
+    DROP COLUMN IF EXISTS goal_fats,
+    DROP COLUMN IF EXISTS goal_carbs;
@@ -0,0 +1,7 @@
+-- Daily calorie and macro goals (0 means no goal)
+
+ALTER TABLE user_preferences
+    ADD COLUMN goal_kcal BIGINT NOT NULL DEFAULT 0 CHECK (goal_kcal >= 0),
+    ADD COLUMN goal_proteins BIGINT NOT NULL DEFAULT 0 CHECK (goal_proteins >= 0),
+    ADD COLUMN goal_fats BIGINT NOT NULL DEFAULT 0 CHECK (goal_fats >= 0),
+    ADD COLUMN goal_carbs BIGINT NOT NULL DEFAULT 0 CHECK (goal_carbs >= 0);
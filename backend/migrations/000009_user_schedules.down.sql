@@ -0,0 +1,5 @@
+-- Rollback scheduled notifications
+
+DROP INDEX IF EXISTS idx_sent_notifications_sent_at;
+DROP TABLE IF EXISTS sent_notifications;
+DROP TABLE IF EXISTS user_schedules;
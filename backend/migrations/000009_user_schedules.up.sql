@@ -0,0 +1,20 @@
+-- Scheduled daily summaries and logging reminders
+
+CREATE TABLE user_schedules (
+    login VARCHAR(255) PRIMARY KEY,
+    chat_id BIGINT NOT NULL,
+    daily_summary BOOLEAN NOT NULL DEFAULT FALSE,
+    reminders TIME[] NOT NULL DEFAULT '{}'
+);
+
+-- Notifications already sent, so restarts never send one twice
+CREATE TABLE sent_notifications (
+    login VARCHAR(255) NOT NULL,
+    kind VARCHAR(32) NOT NULL,
+    day DATE NOT NULL,
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (login, kind, day)
+);
+
+CREATE INDEX idx_sent_notifications_sent_at ON sent_notifications(sent_at);
@@ -0,0 +1,3 @@
+-- Rollback meal categories
+
+ALTER TABLE records DROP COLUMN IF EXISTS meal;
@@ -0,0 +1,4 @@
+-- Meal categories on records (NULL is inferred from the time of day)
+
+ALTER TABLE records
+    ADD COLUMN meal VARCHAR(16) CHECK (meal IN ('breakfast', 'lunch', 'dinner', 'snack'));
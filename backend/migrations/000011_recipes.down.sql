@@ -0,0 +1,4 @@
+-- Rollback recipes
+
+DROP TABLE IF EXISTS recipe_ingredients;
+DROP TABLE IF EXISTS recipes;
@@ -0,0 +1,21 @@
+-- Home-cooked recipes
+--
+-- A recipe's dish is a gram product owned by the cook. Its nutrition per
+-- 100 g is computed from the ingredients and stored on the product, so
+-- records of a dish work like records of any other product.
+
+CREATE TABLE recipes (
+    product_uuid UUID PRIMARY KEY REFERENCES product_details(uuid) ON DELETE CASCADE,
+    cooked_weight NUMERIC(12, 3) NOT NULL CHECK (cooked_weight > 0)
+);
+
+CREATE TABLE recipe_ingredients (
+    recipe_uuid UUID NOT NULL REFERENCES recipes(product_uuid) ON DELETE CASCADE,
+    product_uuid UUID NOT NULL REFERENCES product_details(uuid) ON DELETE CASCADE,
+    amount NUMERIC(12, 3) NOT NULL CHECK (amount > 0),
+    position INT NOT NULL,
+    PRIMARY KEY (recipe_uuid, product_uuid),
+    CHECK (recipe_uuid <> product_uuid)
+);
+
+CREATE INDEX idx_recipe_ingredients_product_uuid ON recipe_ingredients(product_uuid);
@@ -0,0 +1,8 @@
+-- Rollback product barcodes
+
+DROP INDEX IF EXISTS idx_product_details_barcode;
+DROP INDEX IF EXISTS idx_product_details_shared_barcode;
+
+ALTER TABLE product_details
+    DROP COLUMN IF EXISTS brand,
+    DROP COLUMN IF EXISTS barcode;
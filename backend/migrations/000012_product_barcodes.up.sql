@@ -0,0 +1,12 @@
+-- Barcodes and brands of packaged products
+--
+-- Products imported from an Open Food Facts dump are shared and unique by
+-- barcode, so re-importing a newer dump updates them in place.
+
+ALTER TABLE product_details
+    ADD COLUMN barcode VARCHAR(32),
+    ADD COLUMN brand VARCHAR(255);
+
+CREATE UNIQUE INDEX idx_product_details_shared_barcode ON product_details(barcode)
+    WHERE owner IS NULL AND barcode IS NOT NULL;
+CREATE INDEX idx_product_details_barcode ON product_details(barcode);
@@ -0,0 +1,7 @@
+-- Rollback optional user language
+
+UPDATE user_preferences SET lang = 'ru' WHERE lang IS NULL;
+
+ALTER TABLE user_preferences
+    ALTER COLUMN lang SET DEFAULT 'ru',
+    ALTER COLUMN lang SET NOT NULL;
@@ -0,0 +1,5 @@
+-- Users without a chosen language get the language of their Telegram client
+
+ALTER TABLE user_preferences
+    ALTER COLUMN lang DROP NOT NULL,
+    ALTER COLUMN lang SET DEFAULT NULL;
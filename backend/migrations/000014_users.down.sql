@@ -0,0 +1,95 @@
+-- Rollback users keyed by their Telegram ID
+--
+-- Users are keyed by their username again, or user_<id> without one.
+
+CREATE TEMPORARY TABLE user_logins AS
+SELECT id AS user_id, COALESCE(username, 'user_' || id) AS login
+FROM users;
+
+-- Records
+
+ALTER TABLE records ADD COLUMN login VARCHAR(255);
+
+UPDATE records r
+SET login = l.login
+FROM user_logins l
+WHERE l.user_id = r.user_id;
+
+ALTER TABLE records
+    ALTER COLUMN login SET NOT NULL,
+    DROP COLUMN user_id;
+
+CREATE INDEX idx_records_login ON records(login);
+
+-- Product owners
+
+ALTER TABLE product_details ADD COLUMN owner_login VARCHAR(255);
+
+UPDATE product_details p
+SET owner_login = l.login
+FROM user_logins l
+WHERE l.user_id = p.owner;
+
+ALTER TABLE product_details DROP COLUMN owner;
+ALTER TABLE product_details RENAME COLUMN owner_login TO owner;
+
+CREATE INDEX idx_product_details_owner ON product_details(owner);
+CREATE UNIQUE INDEX idx_product_details_shared_barcode ON product_details(barcode)
+    WHERE owner IS NULL AND barcode IS NOT NULL;
+
+-- Preferences and schedules
+
+ALTER TABLE user_preferences ADD COLUMN login VARCHAR(255);
+
+UPDATE user_preferences p
+SET login = l.login
+FROM user_logins l
+WHERE l.user_id = p.user_id;
+
+ALTER TABLE user_preferences
+    DROP COLUMN user_id,
+    ADD PRIMARY KEY (login);
+
+CREATE INDEX idx_user_preferences_login ON user_preferences(login);
+
+ALTER TABLE user_schedules ADD COLUMN login VARCHAR(255);
+
+UPDATE user_schedules s
+SET login = l.login
+FROM user_logins l
+WHERE l.user_id = s.user_id;
+
+ALTER TABLE user_schedules
+    DROP COLUMN user_id,
+    ADD PRIMARY KEY (login);
+
+ALTER TABLE sent_notifications ADD COLUMN login VARCHAR(255);
+
+UPDATE sent_notifications s
+SET login = l.login
+FROM user_logins l
+WHERE l.user_id = s.user_id;
+
+ALTER TABLE sent_notifications
+    DROP COLUMN user_id,
+    ADD PRIMARY KEY (login, kind, day);
+
+-- Common items
+
+ALTER TABLE user_common_items ADD COLUMN login VARCHAR(255);
+
+UPDATE user_common_items c
+SET login = l.login
+FROM user_logins l
+WHERE l.user_id = c.user_id;
+
+ALTER TABLE user_common_items
+    ALTER COLUMN login SET NOT NULL,
+    DROP COLUMN user_id,
+    ADD UNIQUE (login, path);
+
+CREATE INDEX idx_user_common_items_login ON user_common_items(login);
+CREATE INDEX idx_user_common_items_login_path ON user_common_items(login, path);
+
+DROP TABLE user_logins;
+DROP TABLE users;
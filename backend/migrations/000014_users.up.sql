@@ -0,0 +1,174 @@
+-- Users keyed by their Telegram ID
+--
+-- Data used to be keyed by the Telegram username, or user_<id> for users
+-- without one, so it was lost whenever a user set or changed their username.
+-- Users are now identified by their numeric Telegram ID and the username is
+-- only a mutable attribute.
+--
+-- Logins of the form user_<id> and logins with reminders in a private chat
+-- reveal the user's ID. Other logins become placeholder users with a
+-- negative ID and the login as username. Their data stays with the
+-- placeholder until an operator hands it over with
+-- `server claim-user <username> <telegram-id>`, since the username may have
+-- passed to someone else in the meantime.
+
+CREATE TABLE users (
+    id BIGINT PRIMARY KEY,
+    username VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_users_lower_username ON users(LOWER(username));
+
+-- Map every login in use to a user
+
+CREATE TEMPORARY TABLE login_users (
+    login VARCHAR(255) PRIMARY KEY,
+    user_id BIGINT
+);
+
+INSERT INTO login_users (login)
+SELECT login FROM records
+UNION SELECT login FROM user_preferences
+UNION SELECT login FROM user_common_items
+UNION SELECT owner FROM product_details WHERE owner IS NOT NULL
+UNION SELECT login FROM user_schedules
+UNION SELECT login FROM sent_notifications;
+
+UPDATE login_users
+SET user_id = substring(login FROM 6)::BIGINT
+WHERE login ~ '^user_[0-9]{1,18}$';
+
+-- In a private chat the chat ID is the user ID
+UPDATE login_users l
+SET user_id = s.chat_id
+FROM user_schedules s
+WHERE s.login = l.login AND s.chat_id > 0 AND l.user_id IS NULL;
+
+UPDATE login_users l
+SET user_id = -n.n
+FROM (
+    SELECT login, ROW_NUMBER() OVER (ORDER BY login) AS n
+    FROM login_users
+    WHERE user_id IS NULL
+) n
+WHERE n.login = l.login;
+
+INSERT INTO users (id, username)
+SELECT user_id, (ARRAY_AGG(login ORDER BY login) FILTER (WHERE login !~ '^user_[0-9]+$'))[1]
+FROM login_users
+GROUP BY user_id;
+
+-- Records
+
+ALTER TABLE records ADD COLUMN user_id BIGINT REFERENCES users(id) ON UPDATE CASCADE;
+
+UPDATE records r
+SET user_id = l.user_id
+FROM login_users l
+WHERE l.login = r.login;
+
+ALTER TABLE records
+    ALTER COLUMN user_id SET NOT NULL,
+    DROP COLUMN login;
+
+CREATE INDEX idx_records_user_id ON records(user_id);
+
+-- Product owners
+
+ALTER TABLE product_details ADD COLUMN owner_id BIGINT REFERENCES users(id) ON UPDATE CASCADE;
+
+UPDATE product_details p
+SET owner_id = l.user_id
+FROM login_users l
+WHERE l.login = p.owner;
+
+ALTER TABLE product_details DROP COLUMN owner;
+ALTER TABLE product_details RENAME COLUMN owner_id TO owner;
+
+CREATE INDEX idx_product_details_owner ON product_details(owner);
+CREATE UNIQUE INDEX idx_product_details_shared_barcode ON product_details(barcode)
+    WHERE owner IS NULL AND barcode IS NOT NULL;
+
+-- Preferences and schedules; a user with rows under two logins keeps the
+-- ones of their username
+
+ALTER TABLE user_preferences ADD COLUMN user_id BIGINT REFERENCES users(id) ON UPDATE CASCADE;
+
+UPDATE user_preferences p
+SET user_id = l.user_id
+FROM login_users l
+WHERE l.login = p.login;
+
+DELETE FROM user_preferences p
+USING user_preferences q
+WHERE p.user_id = q.user_id
+    AND (p.login !~ '^user_[0-9]+$', p.login) < (q.login !~ '^user_[0-9]+$', q.login);
+
+ALTER TABLE user_preferences
+    DROP COLUMN login,
+    ADD PRIMARY KEY (user_id);
+
+ALTER TABLE user_schedules ADD COLUMN user_id BIGINT REFERENCES users(id) ON UPDATE CASCADE;
+
+UPDATE user_schedules s
+SET user_id = l.user_id
+FROM login_users l
+WHERE l.login = s.login;
+
+DELETE FROM user_schedules s
+USING user_schedules t
+WHERE s.user_id = t.user_id
+    AND (s.login !~ '^user_[0-9]+$', s.login) < (t.login !~ '^user_[0-9]+$', t.login);
+
+ALTER TABLE user_schedules
+    DROP COLUMN login,
+    ADD PRIMARY KEY (user_id);
+
+ALTER TABLE sent_notifications ADD COLUMN user_id BIGINT REFERENCES users(id) ON UPDATE CASCADE;
+
+UPDATE sent_notifications s
+SET user_id = l.user_id
+FROM login_users l
+WHERE l.login = s.login;
+
+DELETE FROM sent_notifications s
+USING sent_notifications t
+WHERE s.user_id = t.user_id AND s.kind = t.kind AND s.day = t.day AND s.login < t.login;
+
+ALTER TABLE sent_notifications
+    DROP COLUMN login,
+    ADD PRIMARY KEY (user_id, kind, day);
+
+-- Common items; top-level items of a second login of the same user that
+-- clash with the first one's get a numeric suffix
+
+ALTER TABLE user_common_items ADD COLUMN user_id BIGINT REFERENCES users(id) ON UPDATE CASCADE;
+
+UPDATE user_common_items c
+SET user_id = l.user_id
+FROM login_users l
+WHERE l.login = c.login;
+
+UPDATE user_common_items c
+SET path = CASE
+    WHEN nlevel(c.path) = 1 THEN text2ltree(ltree2text(r.root) || '_' || r.n)
+    ELSE text2ltree(ltree2text(r.root) || '_' || r.n) || subpath(c.path, 1)
+END
+FROM (
+    SELECT DISTINCT login, root, n
+    FROM (
+        SELECT login, subpath(path, 0, 1) AS root,
+            DENSE_RANK() OVER (PARTITION BY user_id, subpath(path, 0, 1) ORDER BY login) AS n
+        FROM user_common_items
+    ) ranked
+    WHERE n > 1
+) r
+WHERE c.login = r.login AND subpath(c.path, 0, 1) = r.root;
+
+ALTER TABLE user_common_items
+    ALTER COLUMN user_id SET NOT NULL,
+    DROP COLUMN login,
+    ADD UNIQUE (user_id, path);
+
+DROP TABLE login_users;
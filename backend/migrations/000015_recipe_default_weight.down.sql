@@ -0,0 +1,12 @@
+-- Rollback default cooked weights
+
+UPDATE recipes r
+SET cooked_weight = COALESCE((
+    SELECT SUM(i.amount)
+    FROM recipe_ingredients i
+    JOIN product_details p ON p.uuid = i.product_uuid
+    WHERE i.recipe_uuid = r.product_uuid AND p.unit <> 'pcs'
+), 100)
+WHERE r.cooked_weight IS NULL;
+
+ALTER TABLE recipes ALTER COLUMN cooked_weight SET NOT NULL;
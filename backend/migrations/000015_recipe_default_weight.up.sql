@@ -0,0 +1,14 @@
+-- Recipes without a cooked weight given by the cook weigh as much as their
+-- gram and milliliter ingredients, recomputed whenever those change. A NULL
+-- cooked weight stands for that default.
+
+ALTER TABLE recipes ALTER COLUMN cooked_weight DROP NOT NULL;
+
+UPDATE recipes r
+SET cooked_weight = NULL
+WHERE r.cooked_weight = (
+    SELECT SUM(i.amount)
+    FROM recipe_ingredients i
+    JOIN product_details p ON p.uuid = i.product_uuid
+    WHERE i.recipe_uuid = r.product_uuid AND p.unit <> 'pcs'
+);
@@ -0,0 +1,8 @@
+-- Rollback record nutrition
+
+ALTER TABLE records
+    DROP COLUMN IF EXISTS ccal,
+    DROP COLUMN IF EXISTS fats,
+    DROP COLUMN IF EXISTS proteins,
+    DROP COLUMN IF EXISTS carbs,
+    DROP COLUMN IF EXISTS unit;
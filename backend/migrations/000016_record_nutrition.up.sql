@@ -0,0 +1,22 @@
+-- Records keep the nutrition and unit of their product at the time they were
+-- made, so that editing a product or recomputing a recipe does not change
+-- past days. Existing records take the current values of their product.
+
+ALTER TABLE records
+    ADD COLUMN ccal BIGINT,
+    ADD COLUMN fats BIGINT,
+    ADD COLUMN proteins BIGINT,
+    ADD COLUMN carbs BIGINT,
+    ADD COLUMN unit VARCHAR(8) CHECK (unit IN ('g', 'ml', 'pcs'));
+
+UPDATE records r
+SET ccal = p.ccal, fats = p.fats, proteins = p.proteins, carbs = p.carbs, unit = p.unit
+FROM product_details p
+WHERE p.uuid = r.product_uuid;
+
+ALTER TABLE records
+    ALTER COLUMN ccal SET NOT NULL,
+    ALTER COLUMN fats SET NOT NULL,
+    ALTER COLUMN proteins SET NOT NULL,
+    ALTER COLUMN carbs SET NOT NULL,
+    ALTER COLUMN unit SET NOT NULL;